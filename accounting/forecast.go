@@ -0,0 +1,172 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// DefaultForecastDays is the time span of a cash flow forecast, if none is specified
+const DefaultForecastDays = 30
+
+// runningJobStatus are the statuses of jobs whose products are not delivered yet
+var runningJobStatus = map[string]bool{
+	"active": true,
+	"paused": true,
+	"ready":  true,
+}
+
+// NewCashFlowForecast projects the daily wallet balance of a corporation, starting with the latest known balance
+// of all wallet divisions. The products of running manufacturing and reaction jobs are valued at the market
+// price of the trade hub according to the price strategy and count as income on the day the job ends. Jobs that
+// are ready, but not delivered yet, count as income on the first day. Scheduled expenses are subtracted on their
+// date and expenses in the past are subtracted on the first day.
+//
+// Selling the products takes some time and costs fees, so the income is only an upper bound.
+func NewCashFlowForecast(corporationID int32, request *model.CashFlowForecastRequest, hub model.TradeHub, strategy model.PriceStrategy) (forecast *model.CashFlowForecast, err error) {
+	var (
+		balances []model.WalletBalance
+		jobs     []*model.IndustryJobWithTypeNames
+		prices   map[int32]model.Price
+	)
+
+	days := request.Days
+	if days < 1 {
+		days = DefaultForecastDays
+	}
+
+	forecast = &model.CashFlowForecast{
+		CorporationID: corporationID,
+		Hub:           hub,
+		Strategy:      strategy,
+		Days:          []model.CashFlowDay{},
+		Jobs:          []model.ForecastJob{},
+		Expenses:      []model.ScheduledExpense{},
+	}
+
+	if balances, err = db.GetLatestBalances(corporationID); err != nil {
+		return nil, fmt.Errorf("could not retrieve wallet balances: %w", err)
+	}
+
+	for _, balance := range balances {
+		forecast.StartBalance += balance.Balance
+	}
+
+	if jobs, err = db.GetIndustryJobs(corporationID); err != nil {
+		return nil, fmt.Errorf("could not retrieve industry jobs: %w", err)
+	}
+
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	end := start.AddDate(0, 0, days)
+
+	typeIDs := []int32{}
+
+	for _, job := range jobs {
+		if !runningJobStatus[job.Status] || !isProfitActivity(model.IndustryActivityID(job.ActivityID)) || !job.EndDate.Before(end) {
+			continue
+		}
+
+		product, err := db.GetActivityProduct(model.IndustryActivityID(job.ActivityID), job.BlueprintTypeID, job.ProductTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve product of job %d: %w", job.JobID, err)
+		}
+
+		forecast.Jobs = append(forecast.Jobs, model.ForecastJob{
+			JobID:           job.JobID,
+			ProductTypeID:   job.ProductTypeID,
+			ProductTypeName: job.ProductTypeName,
+			Units:           int(job.Runs) * product.Quantity,
+			EndDate:         job.EndDate,
+		})
+
+		typeIDs = append(typeIDs, job.ProductTypeID)
+	}
+
+	if prices, err = cache.GetPrices(hub, typeIDs); err != nil {
+		return nil, fmt.Errorf("could not retrieve prices: %w", err)
+	}
+
+	for i := range forecast.Jobs {
+		job := &forecast.Jobs[i]
+		job.Value = float64(job.Units) * prices[job.ProductTypeID].Value(strategy)
+	}
+
+	for _, expense := range request.Expenses {
+		if expense.Date.Before(end) {
+			forecast.Expenses = append(forecast.Expenses, expense)
+		}
+	}
+
+	forecast.EndBalance = forecast.StartBalance
+	forecast.LowestBalance = forecast.StartBalance
+	forecast.LowestBalanceDate = start
+
+	for i := 0; i < days; i++ {
+		day := model.CashFlowDay{
+			Date: start.AddDate(0, 0, i),
+		}
+
+		for _, job := range forecast.Jobs {
+			if dayOf(job.EndDate, start) == i {
+				day.Income += job.Value
+			}
+		}
+
+		for _, expense := range forecast.Expenses {
+			if dayOf(expense.Date, start) == i {
+				day.Expenses += expense.Amount
+			}
+		}
+
+		forecast.EndBalance += day.Income - day.Expenses
+		day.Balance = forecast.EndBalance
+
+		if day.Balance < forecast.LowestBalance {
+			forecast.LowestBalance = day.Balance
+			forecast.LowestBalanceDate = day.Date
+		}
+
+		forecast.Days = append(forecast.Days, day)
+	}
+
+	return forecast, nil
+}
+
+// dayOf returns the day of the forecast a date belongs to. Dates before the start belong to the first day.
+func dayOf(date time.Time, start time.Time) int {
+	if date.Before(start) {
+		return 0
+	}
+
+	return int(date.Sub(start) / (24 * time.Hour))
+}
+
+// isProfitActivity returns true, if the products of the activity are tracked
+func isProfitActivity(activityID model.IndustryActivityID) bool {
+	for _, id := range profitActivities {
+		if id == activityID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// NewHoldings computes the items a corporation still holds after replaying all its transactions and jobs, valued
+// with the specified cost basis method. The unrealized gain is the difference between the current market value,
+// according to the trade hub and price strategy, and the cost basis.
+//
+// Only items that were bought or built are known. Items that were used or sold in other ways, for example in jobs
+// that are still running or in contracts, are still part of the holdings.
+func NewHoldings(corporationID int32, method model.CostBasisMethod, hub model.TradeHub, strategy model.PriceStrategy) (holdings *model.Holdings, err error) {
+	var (
+		ledger Ledger
+		prices map[int32]model.Price
+	)
+
+	if ledger, err = NewLedger(method); err != nil {
+		return nil, err
+	}
+
+	if _, err = replay(corporationID, ledger); err != nil {
+		return nil, err
+	}
+
+	typeIDs := ledger.TypeIDs()
+
+	if prices, err = cache.GetPrices(hub, typeIDs); err != nil {
+		return nil, fmt.Errorf("could not retrieve prices: %w", err)
+	}
+
+	holdings = &model.Holdings{
+		CorporationID: corporationID,
+		Method:        method,
+		Hub:           hub,
+		Strategy:      strategy,
+		Types:         []model.TypeHolding{},
+	}
+
+	for _, typeID := range typeIDs {
+		holding := newTypeHolding(typeID, ledger.Remaining(typeID))
+		if holding.Quantity == 0 {
+			continue
+		}
+
+		if t, err := db.GetType(typeID); err == nil {
+			holding.TypeName = t.TypeName
+		}
+
+		holding.MarketPricePerUnit = prices[typeID].Value(strategy)
+		holding.MarketValue = float64(holding.Quantity) * holding.MarketPricePerUnit
+		holding.UnrealizedGain = holding.MarketValue - holding.CostBasis
+
+		holdings.Types = append(holdings.Types, holding)
+		holdings.CostBasis += holding.CostBasis
+		holdings.MarketValue += holding.MarketValue
+		holdings.UnrealizedGain += holding.UnrealizedGain
+	}
+
+	return holdings, nil
+}
+
+// newTypeHolding sums up the remaining lots of a type per location
+func newTypeHolding(typeID int32, lots []Lot) model.TypeHolding {
+	holding := model.TypeHolding{
+		TypeID:    typeID,
+		Locations: []model.LocationHolding{},
+	}
+
+	locations := map[int64]*model.LocationHolding{}
+
+	for _, lot := range lots {
+		location, ok := locations[lot.LocationID]
+		if !ok {
+			location = &model.LocationHolding{LocationID: lot.LocationID}
+			locations[lot.LocationID] = location
+		}
+
+		location.Quantity += lot.Quantity
+		location.CostBasis += float64(lot.Quantity) * lot.UnitCost
+
+		holding.Quantity += lot.Quantity
+		holding.CostBasis += float64(lot.Quantity) * lot.UnitCost
+	}
+
+	for _, location := range locations {
+		holding.Locations = append(holding.Locations, *location)
+	}
+
+	sort.Slice(holding.Locations, func(i, j int) bool {
+		return holding.Locations[i].LocationID < holding.Locations[j].LocationID
+	})
+
+	if holding.Quantity > 0 {
+		holding.AverageCost = holding.CostBasis / float64(holding.Quantity)
+	}
+
+	return holding
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package accounting relates the transactions and industry jobs of a corporation to each other, so that the
+// actual costs and profits can be compared to the predicted ones
+package accounting
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/oxisto/titan/model"
+)
+
+// Lot is a quantity of items of the same type that were acquired at the same unit cost, either by buying or by
+// building them
+type Lot struct {
+	Quantity int
+	UnitCost float64
+	// JobID is the industry job that built the items or 0, if they were bought
+	JobID int32
+	// LocationID is where the items were bought or delivered to. Items that are hauled somewhere else are not
+	// tracked, so this is only the location the lot entered the corporation at.
+	LocationID int64
+}
+
+// Ledger keeps track of the lots of all types the corporation holds. The cost basis method of the ledger decides
+// which lots are taken and at which cost.
+type Ledger interface {
+	// Add adds a new lot of a type
+	Add(typeID int32, lot Lot)
+	// Take removes the quantity of a type from the ledger. It returns the parts of the lots that were taken and
+	// the quantity that was missing, because not enough items were left.
+	Take(typeID int32, quantity int) (taken []Lot, missing int)
+	// Remaining returns all lots of a type that are left
+	Remaining(typeID int32) []Lot
+	// TypeIDs returns all types that have lots left
+	TypeIDs() []int32
+}
+
+// NewLedger creates an empty ledger for the specified cost basis method
+func NewLedger(method model.CostBasisMethod) (Ledger, error) {
+	switch method {
+	case model.CostBasisFIFO:
+		return NewFIFO(), nil
+	case model.CostBasisWeightedAverage:
+		return NewWeightedAverage(), nil
+	default:
+		return nil, fmt.Errorf("unknown cost basis method %q", method)
+	}
+}
+
+// FIFO keeps track of the lots per type. Items are always taken from the oldest lot first.
+type FIFO struct {
+	lots map[int32][]*Lot
+}
+
+func NewFIFO() *FIFO {
+	return &FIFO{
+		lots: map[int32][]*Lot{},
+	}
+}
+
+// Add adds a new lot of a type
+func (f *FIFO) Add(typeID int32, lot Lot) {
+	if lot.Quantity <= 0 {
+		return
+	}
+
+	f.lots[typeID] = append(f.lots[typeID], &lot)
+}
+
+// Take removes the quantity of a type from the oldest lots
+func (f *FIFO) Take(typeID int32, quantity int) (taken []Lot, missing int) {
+	lots := f.lots[typeID]
+
+	for quantity > 0 && len(lots) > 0 {
+		lot := lots[0]
+
+		n := lot.Quantity
+		if n > quantity {
+			n = quantity
+		}
+
+		part := *lot
+		part.Quantity = n
+
+		taken = append(taken, part)
+
+		lot.Quantity -= n
+		quantity -= n
+
+		if lot.Quantity == 0 {
+			lots = lots[1:]
+		}
+	}
+
+	f.lots[typeID] = lots
+
+	return taken, quantity
+}
+
+// Remaining returns all lots of a type that are left
+func (f *FIFO) Remaining(typeID int32) []Lot {
+	remaining := []Lot{}
+
+	for _, lot := range f.lots[typeID] {
+		remaining = append(remaining, *lot)
+	}
+
+	return remaining
+}
+
+// TypeIDs returns all types that have lots left, ordered by their ID
+func (f *FIFO) TypeIDs() []int32 {
+	typeIDs := []int32{}
+
+	for typeID, lots := range f.lots {
+		if len(lots) > 0 {
+			typeIDs = append(typeIDs, typeID)
+		}
+	}
+
+	sort.Slice(typeIDs, func(i, j int) bool {
+		return typeIDs[i] < typeIDs[j]
+	})
+
+	return typeIDs
+}
+
+// WeightedAverage values all items of a type at the average cost of all lots that are held. Whenever a lot is
+// added, the average cost is updated for all lots of the type. Items are still taken from the oldest lot first,
+// so that the job that built them and their location are known.
+type WeightedAverage struct {
+	*FIFO
+}
+
+func NewWeightedAverage() *WeightedAverage {
+	return &WeightedAverage{
+		FIFO: NewFIFO(),
+	}
+}
+
+// Add adds a new lot of a type and updates the average cost of the type
+func (w *WeightedAverage) Add(typeID int32, lot Lot) {
+	w.FIFO.Add(typeID, lot)
+
+	var (
+		quantity int
+		cost     float64
+	)
+
+	lots := w.lots[typeID]
+	for _, lot := range lots {
+		quantity += lot.Quantity
+		cost += float64(lot.Quantity) * lot.UnitCost
+	}
+
+	if quantity == 0 {
+		return
+	}
+
+	for _, lot := range lots {
+		lot.UnitCost = cost / float64(quantity)
+	}
+}
+
+// Cost returns the total cost of lots
+func Cost(lots []Lot) (cost float64) {
+	for _, lot := range lots {
+		cost += float64(lot.Quantity) * lot.UnitCost
+	}
+
+	return cost
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oxisto/titan/model"
+)
+
+func TestLedger(t *testing.T) {
+	const typeID = 34
+
+	type fields struct {
+		method model.CostBasisMethod
+		// lots are added before the first take
+		lots      []Lot
+		firstTake int
+		// moreLots are added after the first take
+		moreLots []Lot
+	}
+	type args struct {
+		quantity int
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantTaken     []Lot
+		wantMissing   int
+		wantRemaining []Lot
+	}{
+		{
+			name: "FIFO partial take",
+			fields: fields{
+				method: model.CostBasisFIFO,
+				lots:   []Lot{{Quantity: 10, UnitCost: 5}, {Quantity: 10, UnitCost: 7}},
+			},
+			args:          args{quantity: 15},
+			wantTaken:     []Lot{{Quantity: 10, UnitCost: 5}, {Quantity: 5, UnitCost: 7}},
+			wantRemaining: []Lot{{Quantity: 5, UnitCost: 7}},
+		},
+		{
+			name: "FIFO missing quantity",
+			fields: fields{
+				method: model.CostBasisFIFO,
+				lots:   []Lot{{Quantity: 10, UnitCost: 5, JobID: 1}},
+			},
+			args:        args{quantity: 15},
+			wantTaken:   []Lot{{Quantity: 10, UnitCost: 5, JobID: 1}},
+			wantMissing: 5,
+		},
+		{
+			name:        "FIFO empty",
+			fields:      fields{method: model.CostBasisFIFO},
+			args:        args{quantity: 4},
+			wantMissing: 4,
+		},
+		{
+			name: "FIFO after partial take",
+			fields: fields{
+				method:    model.CostBasisFIFO,
+				lots:      []Lot{{Quantity: 10, UnitCost: 4}},
+				firstTake: 5,
+				moreLots:  []Lot{{Quantity: 5, UnitCost: 8}},
+			},
+			args:          args{quantity: 7},
+			wantTaken:     []Lot{{Quantity: 5, UnitCost: 4}, {Quantity: 2, UnitCost: 8}},
+			wantRemaining: []Lot{{Quantity: 3, UnitCost: 8}},
+		},
+		{
+			name: "weighted average partial take",
+			fields: fields{
+				method: model.CostBasisWeightedAverage,
+				lots:   []Lot{{Quantity: 10, UnitCost: 5}, {Quantity: 10, UnitCost: 7}},
+			},
+			args:          args{quantity: 15},
+			wantTaken:     []Lot{{Quantity: 10, UnitCost: 6}, {Quantity: 5, UnitCost: 6}},
+			wantRemaining: []Lot{{Quantity: 5, UnitCost: 6}},
+		},
+		{
+			name: "weighted average missing quantity",
+			fields: fields{
+				method: model.CostBasisWeightedAverage,
+				lots:   []Lot{{Quantity: 10, UnitCost: 5}},
+			},
+			args:        args{quantity: 12},
+			wantTaken:   []Lot{{Quantity: 10, UnitCost: 5}},
+			wantMissing: 2,
+		},
+		{
+			name: "weighted average after partial take",
+			fields: fields{
+				method:    model.CostBasisWeightedAverage,
+				lots:      []Lot{{Quantity: 10, UnitCost: 4}},
+				firstTake: 5,
+				moreLots:  []Lot{{Quantity: 5, UnitCost: 8}},
+			},
+			args:          args{quantity: 7},
+			wantTaken:     []Lot{{Quantity: 5, UnitCost: 6}, {Quantity: 2, UnitCost: 6}},
+			wantRemaining: []Lot{{Quantity: 3, UnitCost: 6}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, err := NewLedger(tt.fields.method)
+			if err != nil {
+				t.Fatalf("NewLedger() error = %v", err)
+			}
+
+			for _, lot := range tt.fields.lots {
+				ledger.Add(typeID, lot)
+			}
+
+			if tt.fields.firstTake > 0 {
+				ledger.Take(typeID, tt.fields.firstTake)
+			}
+
+			for _, lot := range tt.fields.moreLots {
+				ledger.Add(typeID, lot)
+			}
+
+			gotTaken, gotMissing := ledger.Take(typeID, tt.args.quantity)
+			if !equalLots(gotTaken, tt.wantTaken) {
+				t.Errorf("Take() gotTaken = %v, want %v", gotTaken, tt.wantTaken)
+			}
+			if gotMissing != tt.wantMissing {
+				t.Errorf("Take() gotMissing = %v, want %v", gotMissing, tt.wantMissing)
+			}
+			if gotRemaining := ledger.Remaining(typeID); !equalLots(gotRemaining, tt.wantRemaining) {
+				t.Errorf("Remaining() = %v, want %v", gotRemaining, tt.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestNewLedgerUnknownMethod(t *testing.T) {
+	if _, err := NewLedger("lifo"); err == nil {
+		t.Error("NewLedger() expected an error for an unknown method")
+	}
+}
+
+// equalLots compares lots, treating nil and empty slices as equal
+func equalLots(a []Lot, b []Lot) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
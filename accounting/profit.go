@@ -0,0 +1,111 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
+	"github.com/oxisto/titan/model"
+	"github.com/sirupsen/logrus"
+)
+
+var log *logrus.Entry
+
+func init() {
+	log = logrus.WithField("component", "accounting")
+}
+
+// NewRealizedProfit computes the realized profit of all delivered manufacturing and reaction jobs of a corporation.
+// The transactions and jobs are replayed using FIFO lots, so that sales are matched to the oldest products and
+// jobs use the oldest materials.
+func NewRealizedProfit(corporationID int32) (profit *model.RealizedProfit, err error) {
+	var h *history
+
+	if h, err = replay(corporationID, NewFIFO()); err != nil {
+		return nil, err
+	}
+
+	profit = &model.RealizedProfit{
+		CorporationID: corporationID,
+		Jobs:          []model.JobProfit{},
+	}
+
+	for _, job := range h.jobs {
+		profit.Jobs = append(profit.Jobs, h.ledgers[job.JobID].profit)
+	}
+
+	profit.Products = newProductProfits(corporationID, h)
+
+	return profit, nil
+}
+
+// newProductProfits sums up the realized profit of all jobs per product and compares it to the profit that is
+// currently predicted for the product
+func newProductProfits(corporationID int32, h *history) []model.ProductProfit {
+	products := map[int32]*model.ProductProfit{}
+	typeIDs := []int32{}
+
+	for _, job := range h.jobs {
+		ledger := h.ledgers[job.JobID]
+
+		product, ok := products[job.ProductTypeID]
+		if !ok {
+			product = &model.ProductProfit{ProductTypeID: job.ProductTypeID}
+			products[job.ProductTypeID] = product
+			typeIDs = append(typeIDs, job.ProductTypeID)
+		}
+
+		product.Jobs++
+
+		if !ledger.profit.MaterialEfficiencyKnown {
+			product.JobsWithUnknownMaterialEfficiency++
+		}
+		product.Units += ledger.profit.Units
+		product.SoldUnits += ledger.profit.SoldUnits
+		product.Revenue += ledger.profit.Revenue
+		product.CostOfSoldUnits += ledger.soldCost
+		product.RealizedProfit += ledger.profit.RealizedProfit
+	}
+
+	profits := []model.ProductProfit{}
+
+	for _, typeID := range typeIDs {
+		product := products[typeID]
+
+		if product.SoldUnits > 0 {
+			product.RealizedProfitPerItem = product.RealizedProfit / float64(product.SoldUnits)
+		}
+
+		if t, err := db.GetType(typeID); err == nil {
+			product.ProductTypeName = t.TypeName
+		}
+
+		options := manufacturing.NewOptions()
+		options.CorporationID = corporationID
+
+		m := model.Manufacturing{}
+		if err := manufacturing.NewManufacturing(nil, typeID, options, &m); err != nil {
+			log.Warnf("Could not predict profit of type %d: %v", typeID, err)
+		} else {
+			product.PredictedProfitPerItem = m.Profit.PerItem.BasedOnStrategy
+		}
+
+		profits = append(profits, *product)
+	}
+
+	return profits
+}
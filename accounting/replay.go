@@ -0,0 +1,324 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
+	"github.com/oxisto/titan/model"
+)
+
+// profitActivities are the industry activities whose products are tracked
+var profitActivities = []model.IndustryActivityID{manufacturing.ActivityManufacturing, manufacturing.ActivityReaction}
+
+// event is something that changes the lots of the ledger at a certain point in time. If several events happen
+// at the same time, they are applied in the order of their kind, so that materials bought in the same second
+// are available to a job and products are available before they are sold.
+type event struct {
+	date  time.Time
+	kind  int
+	apply func() error
+}
+
+const (
+	eventBuy = iota
+	eventJobStart
+	eventJobEnd
+	eventSell
+)
+
+// jobLedger holds the realized profit of a job while the events are replayed
+type jobLedger struct {
+	job         model.IndustryJob
+	profit      model.JobProfit
+	soldCost    float64
+	blueprintME int32
+	// facility is the configured facility the job was installed in or nil, if it is not known
+	facility *model.Facility
+}
+
+// history is the result of replaying all transactions and jobs of a corporation
+type history struct {
+	jobs    []model.IndustryJob
+	ledgers map[int32]*jobLedger
+}
+
+// replay replays all transactions and delivered manufacturing and reaction jobs of a corporation in chronological
+// order. Bought items and built products are added to the ledger as lots, so that the materials of a job are
+// taken out of the ledger and its products carry the cost of these materials plus the job installation cost.
+// Sold items are taken out of the ledger as well and their revenue is attributed to the job that built them.
+//
+// The materials of a job are calculated from its runs, the material efficiency of its blueprint, if the blueprint
+// is still owned by the corporation, and the bonuses of the facility it was installed in. Broker fees and sales
+// tax are part of the journal and not included.
+func replay(corporationID int32, ledger Ledger) (h *history, err error) {
+	var (
+		transactions []model.Transaction
+		blueprints   []*model.CorporationBlueprintWithTypeNames
+		facilities   []model.Facility
+		systems      map[int64]int32
+	)
+
+	h = &history{
+		ledgers: map[int32]*jobLedger{},
+	}
+
+	if transactions, err = db.GetTransactions(corporationID); err != nil {
+		return nil, fmt.Errorf("could not retrieve transactions: %w", err)
+	}
+
+	if h.jobs, err = db.GetDeliveredIndustryJobs(corporationID, profitActivities); err != nil {
+		return nil, fmt.Errorf("could not retrieve industry jobs: %w", err)
+	}
+
+	if blueprints, err = db.GetCorporationBlueprints(corporationID); err != nil {
+		return nil, fmt.Errorf("could not retrieve blueprints: %w", err)
+	}
+
+	materialEfficiency := map[int64]int32{}
+	for _, blueprint := range blueprints {
+		materialEfficiency[blueprint.ItemID] = blueprint.MaterialEfficiency
+	}
+
+	if facilities, err = db.GetFacilities(corporationID); err != nil {
+		return nil, fmt.Errorf("could not retrieve facilities: %w", err)
+	}
+
+	facilityIDs := []int64{}
+	for _, job := range h.jobs {
+		facilityIDs = append(facilityIDs, job.FacilityID)
+	}
+
+	if systems, err = db.GetLocationSolarSystems(facilityIDs); err != nil {
+		return nil, fmt.Errorf("could not retrieve solar systems of facilities: %w", err)
+	}
+
+	events := []event{}
+
+	for i := range transactions {
+		transaction := transactions[i]
+
+		if transaction.IsBuy {
+			events = append(events, event{transaction.Date, eventBuy, func() error {
+				ledger.Add(int32(transaction.TypeID), Lot{
+					Quantity:   transaction.Quantity,
+					UnitCost:   transaction.UnitPrice,
+					LocationID: transaction.LocationID,
+				})
+				return nil
+			}})
+		} else {
+			events = append(events, event{transaction.Date, eventSell, func() error {
+				h.sell(ledger, transaction)
+				return nil
+			}})
+		}
+	}
+
+	for _, job := range h.jobs {
+		me, known := materialEfficiency[job.BlueprintID]
+
+		jl := &jobLedger{
+			job: job,
+			profit: model.JobProfit{
+				JobID:                   job.JobID,
+				ProductTypeID:           job.ProductTypeID,
+				JobCost:                 job.Cost,
+				MaterialEfficiencyKnown: known,
+			},
+			blueprintME: me,
+			facility:    jobFacility(job, facilities, systems),
+		}
+
+		if jl.facility != nil {
+			jl.profit.Facility = jl.facility.Name
+		}
+
+		h.ledgers[job.JobID] = jl
+
+		endDate := job.EndDate
+		if job.CompletedDate != nil {
+			endDate = *job.CompletedDate
+		}
+
+		events = append(events,
+			event{job.StartDate, eventJobStart, func() error { return jl.start(ledger) }},
+			event{endDate, eventJobEnd, func() error { return jl.end(ledger) }})
+	}
+
+	sortEvents(events)
+
+	for _, e := range events {
+		if err = e.apply(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, jl := range h.ledgers {
+		jl.profit.RealizedProfit = jl.profit.Revenue - jl.soldCost
+	}
+
+	return h, nil
+}
+
+// sortEvents sorts the events chronologically. Events at the same time are sorted by their kind and otherwise keep
+// their order.
+func sortEvents(events []event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].date.Equal(events[j].date) {
+			return events[i].kind < events[j].kind
+		}
+
+		return events[i].date.Before(events[j].date)
+	})
+}
+
+// jobFacility returns the facility a job was installed in. NPC stations have no bonuses. Structures are matched
+// to the first configured facility in the same solar system, that can install the activity of the job, because
+// their rigs are only known from the configuration. It returns nil, if no facility matches.
+func jobFacility(job model.IndustryJob, facilities []model.Facility, systems map[int64]int32) *model.Facility {
+	activityID := model.IndustryActivityID(job.ActivityID)
+	solarSystemID, ok := systems[job.FacilityID]
+
+	if job.FacilityID < db.MinStructureID {
+		return &model.Facility{
+			Name:          model.StructureNPCStation,
+			StructureType: model.StructureNPCStation,
+			SolarSystemID: solarSystemID,
+			Security:      model.SecurityHigh,
+			Rigs:          model.FacilityRigs{},
+		}
+	}
+
+	if !ok {
+		return nil
+	}
+
+	for i := range facilities {
+		facility := &facilities[i]
+
+		if _, ok := manufacturing.FacilityStructures[facility.StructureType][activityID]; ok && facility.SolarSystemID == solarSystemID {
+			return facility
+		}
+	}
+
+	return nil
+}
+
+// materialModifier returns the material modifier of the job, which is based on the material efficiency of its
+// blueprint and the bonuses of its facility
+func (jl *jobLedger) materialModifier() (float64, error) {
+	job := jl.job
+	activityID := model.IndustryActivityID(job.ActivityID)
+
+	modifiers := map[string]float64{
+		"Blueprint Material Efficiency": -float64(jl.blueprintME) / 100,
+	}
+
+	if jl.facility != nil {
+		product, err := db.GetType(job.ProductTypeID)
+		if err != nil {
+			return 0, fmt.Errorf("could not retrieve product of job %d: %w", job.JobID, err)
+		}
+
+		// a facility that cannot install the job does not stop the replay, its bonuses are just not applied
+		facilityModifiers, err := manufacturing.NewFacilityModifiers(jl.facility, activityID, product)
+		if err != nil {
+			log.Warnf("Could not apply facility of job %d: %v", job.JobID, err)
+			jl.profit.Facility = ""
+		} else {
+			for key, mod := range facilityModifiers.Material {
+				modifiers[key] = mod
+			}
+		}
+	}
+
+	return manufacturing.CalculateModifier(modifiers), nil
+}
+
+// start takes the materials of the job out of the ledger
+func (jl *jobLedger) start(ledger Ledger) error {
+	job := jl.job
+	activityID := model.IndustryActivityID(job.ActivityID)
+
+	materialModifier, err := jl.materialModifier()
+	if err != nil {
+		return err
+	}
+
+	materials, err := db.GetActivityMaterials(activityID, model.Blueprint{TypeID: job.BlueprintTypeID}, int(job.Runs), materialModifier)
+	if err != nil {
+		return fmt.Errorf("could not retrieve materials of job %d: %w", job.JobID, err)
+	}
+
+	for _, material := range materials {
+		taken, missing := ledger.Take(material.TypeID, material.Quantity)
+
+		jl.profit.MaterialCost += Cost(taken)
+		jl.profit.UncoveredMaterials += missing
+	}
+
+	return nil
+}
+
+// end puts the products of the job into the ledger, carrying the cost of the job
+func (jl *jobLedger) end(ledger Ledger) error {
+	job := jl.job
+	activityID := model.IndustryActivityID(job.ActivityID)
+
+	product, err := db.GetActivityProduct(activityID, job.BlueprintTypeID, job.ProductTypeID)
+	if err != nil {
+		return fmt.Errorf("could not retrieve product of job %d: %w", job.JobID, err)
+	}
+
+	jl.profit.Units = int(job.Runs) * product.Quantity
+	if jl.profit.Units == 0 {
+		return nil
+	}
+
+	jl.profit.CostPerUnit = (jl.profit.MaterialCost + jl.profit.JobCost) / float64(jl.profit.Units)
+
+	ledger.Add(job.ProductTypeID, Lot{
+		Quantity:   jl.profit.Units,
+		UnitCost:   jl.profit.CostPerUnit,
+		JobID:      job.JobID,
+		LocationID: job.OutputLocationID,
+	})
+
+	return nil
+}
+
+// sell takes the sold items out of the ledger and attributes the revenue to the jobs that built them
+func (h *history) sell(ledger Ledger, transaction model.Transaction) {
+	taken, _ := ledger.Take(int32(transaction.TypeID), transaction.Quantity)
+
+	for _, lot := range taken {
+		jl, ok := h.ledgers[lot.JobID]
+		if !ok {
+			// the items were bought, so this is trading and not part of our industry profit
+			continue
+		}
+
+		jl.profit.SoldUnits += lot.Quantity
+		jl.profit.Revenue += float64(lot.Quantity) * transaction.UnitPrice
+		jl.soldCost += float64(lot.Quantity) * lot.UnitCost
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortEvents(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		events []event
+		want   []int
+	}{
+		{
+			name: "chronological",
+			events: []event{
+				{date: now.Add(time.Hour), kind: eventBuy},
+				{date: now, kind: eventSell},
+			},
+			want: []int{eventSell, eventBuy},
+		},
+		{
+			name: "same time by kind",
+			events: []event{
+				{date: now, kind: eventSell},
+				{date: now, kind: eventJobEnd},
+				{date: now, kind: eventJobStart},
+				{date: now, kind: eventBuy},
+			},
+			want: []int{eventBuy, eventJobStart, eventJobEnd, eventSell},
+		},
+		{
+			name: "same time in another location",
+			events: []event{
+				{date: now.In(time.FixedZone("UTC+2", 2*3600)), kind: eventSell},
+				{date: now, kind: eventBuy},
+			},
+			want: []int{eventBuy, eventSell},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortEvents(tt.events)
+
+			got := []int{}
+			for _, e := range tt.events {
+				got = append(got, e.kind)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortEventsKeepsOrder(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	applied := []int{}
+
+	events := []event{}
+	for i := 0; i < 3; i++ {
+		i := i
+		events = append(events, event{now, eventBuy, func() error {
+			applied = append(applied, i)
+			return nil
+		}})
+	}
+
+	sortEvents(events)
+
+	for _, e := range events {
+		e.apply()
+	}
+
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("sortEvents() applied = %v, want %v", applied, want)
+	}
+}
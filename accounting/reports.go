@@ -0,0 +1,134 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// NewProfitAndLoss creates a profit and loss report out of the wallet journal of a corporation. The journal
+// entries between from and to are grouped by period, wallet division and report category. If division is 0,
+// all divisions are included.
+func NewProfitAndLoss(corporationID int32, period model.ReportPeriod, division int32, from time.Time, to time.Time) (report *model.ProfitAndLoss, err error) {
+	var sums []db.JournalSumResult
+
+	if sums, err = db.GetJournalSums(corporationID, period, division, from, to); err != nil {
+		return nil, err
+	}
+
+	report = &model.ProfitAndLoss{
+		CorporationID: corporationID,
+		Period:        period,
+		From:          from,
+		To:            to,
+		Rows:          []model.ProfitAndLossRow{},
+	}
+
+	addJournalSums(report, sums)
+
+	return report, nil
+}
+
+// addJournalSums groups the journal sums by period, wallet division and report category into the rows of a report
+// and adds them to its totals. The rows are sorted by period, division and category.
+func addJournalSums(report *model.ProfitAndLoss, sums []db.JournalSumResult) {
+	type key struct {
+		start    time.Time
+		division int32
+		category model.ReportCategory
+	}
+
+	rows := map[key]*model.ProfitAndLossRow{}
+
+	for _, sum := range sums {
+		k := key{sum.Start.UTC(), sum.Division, model.NewReportCategory(sum.RefType)}
+
+		row, ok := rows[k]
+		if !ok {
+			row = &model.ProfitAndLossRow{
+				Start:    k.start,
+				Division: k.division,
+				Category: k.category,
+			}
+			rows[k] = row
+		}
+
+		row.Income += sum.Income
+		row.Expenses += sum.Expenses
+		row.Net += sum.Income + sum.Expenses
+		row.Entries += sum.Entries
+
+		report.Income += sum.Income
+		report.Expenses += sum.Expenses
+		report.Net += sum.Income + sum.Expenses
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+
+		if a.Division != b.Division {
+			return a.Division < b.Division
+		}
+
+		return a.Category < b.Category
+	})
+}
+
+// WriteProfitAndLossCSV writes the rows of a profit and loss report as CSV, so that it can be imported into
+// a spreadsheet
+func WriteProfitAndLossCSV(w io.Writer, report *model.ProfitAndLoss) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"start", "division", "category", "income", "expenses", "net", "entries"}); err != nil {
+		return err
+	}
+
+	for _, row := range report.Rows {
+		record := []string{
+			row.Start.Format("2006-01-02"),
+			strconv.Itoa(int(row.Division)),
+			string(row.Category),
+			strconv.FormatFloat(row.Income, 'f', 2, 64),
+			strconv.FormatFloat(row.Expenses, 'f', 2, 64),
+			strconv.FormatFloat(row.Net, 'f', 2, 64),
+			strconv.Itoa(row.Entries),
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
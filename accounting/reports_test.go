@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+func TestAddJournalSums(t *testing.T) {
+	day1 := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2020, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	type want struct {
+		rows     []model.ProfitAndLossRow
+		income   float64
+		expenses float64
+		net      float64
+	}
+	tests := []struct {
+		name string
+		sums []db.JournalSumResult
+		want want
+	}{
+		{
+			name: "grouped and sorted",
+			sums: []db.JournalSumResult{
+				{Start: day2, Division: 1, RefType: "market_transaction", Income: 100, Entries: 2},
+				{Start: day1, Division: 2, RefType: "player_donation", Income: 30, Entries: 1},
+				{Start: day1, Division: 1, RefType: "brokers_fee", Expenses: -10, Entries: 1},
+				{Start: day1, Division: 1, RefType: "market_escrow", Expenses: -50, Entries: 1},
+				// the start of a period is compared in UTC
+				{Start: day1.In(time.FixedZone("CEST", 2*60*60)), Division: 1, RefType: "market_transaction", Income: 200, Entries: 3},
+				{Start: day1, Division: 1, RefType: "unknown_ref_type", Income: 5, Expenses: -1, Entries: 2},
+			},
+			want: want{
+				rows: []model.ProfitAndLossRow{
+					{Start: day1, Division: 1, Category: model.ReportCategoryBrokerFees, Expenses: -10, Net: -10, Entries: 1},
+					{Start: day1, Division: 1, Category: model.ReportCategoryMarket, Income: 200, Expenses: -50, Net: 150, Entries: 4},
+					{Start: day1, Division: 1, Category: model.ReportCategoryOther, Income: 5, Expenses: -1, Net: 4, Entries: 2},
+					{Start: day1, Division: 2, Category: model.ReportCategoryDonations, Income: 30, Net: 30, Entries: 1},
+					{Start: day2, Division: 1, Category: model.ReportCategoryMarket, Income: 100, Net: 100, Entries: 2},
+				},
+				income:   335,
+				expenses: -61,
+				net:      274,
+			},
+		},
+		{
+			name: "no journal entries",
+			want: want{rows: []model.ProfitAndLossRow{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &model.ProfitAndLoss{Rows: []model.ProfitAndLossRow{}}
+
+			addJournalSums(report, tt.sums)
+
+			if !reflect.DeepEqual(report.Rows, tt.want.rows) {
+				t.Errorf("addJournalSums() rows = %v, want %v", report.Rows, tt.want.rows)
+			}
+			if report.Income != tt.want.income || report.Expenses != tt.want.expenses || report.Net != tt.want.net {
+				t.Errorf("addJournalSums() totals = %v, %v, %v, want %v, %v, %v",
+					report.Income, report.Expenses, report.Net, tt.want.income, tt.want.expenses, tt.want.net)
+			}
+		})
+	}
+}
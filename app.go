@@ -41,7 +41,6 @@ func init() {
 // App represented the main titan application
 type App struct {
 	CacheManufacturing bool
-	CorporationID      int32
 }
 
 // ImportSDE reads the current SDE version from sde.version and imports it into the DB, if necessary.
@@ -103,6 +102,9 @@ func (a App) ServerLoop() {
 	typeIDs = append(typeIDs, db.GetTech1BlueprintIDs()...)
 	typeIDs = append(typeIDs, db.GetMaterialTypeIDs(manufacturing.ActivityManufacturing)...)
 	typeIDs = append(typeIDs, db.GetMaterialTypeIDs(manufacturing.ActivityInvention)...)
+	typeIDs = append(typeIDs, db.GetMaterialTypeIDs(manufacturing.ActivityReaction)...)
+	typeIDs = append(typeIDs, manufacturing.DecryptorTypeIDs()...)
+	typeIDs = append(typeIDs, db.GetRelicTypeIDs()...)
 
 	uniqueTypeIDs := MakeUnique(typeIDs)
 
@@ -110,12 +112,15 @@ func (a App) ServerLoop() {
 	for {
 		log.Printf("Need to know the price of %d unique types.", len(uniqueTypeIDs))
 
-		cache.GetPrices(model.JitaRegionID, uniqueTypeIDs)
+		// fetched prices are stored as a snapshot of the price history
+		if _, err = cache.GetPrices(manufacturing.DefaultTradeHub, uniqueTypeIDs); err != nil {
+			log.Errorf("Could not retrieve prices: %v", err)
+		}
 
 		log.Printf("Trying to calculate profit for %d types...", len(productTypeIDs))
 
 		/*for _, typeID := range productTypeIDs {
-			go a.UpdateProduct(typeID)
+			go a.UpdateProduct(corporationID, typeID)
 		}*/
 
 		time.Sleep(time.Duration(1) * time.Hour)
@@ -146,11 +151,15 @@ func MakeUnique(slice []int32) []int32 {
 	return u
 }
 
-func (a App) UpdateProduct(typeID int32) {
+// UpdateProduct calculates the profit of a product for a corporation, taking its owned blueprints into account
+func (a App) UpdateProduct(corporationID int32, typeID int32) {
 	m := model.Manufacturing{}
 
-	if err := manufacturing.NewManufacturing(nil, int32(typeID), 10, 20, 0.1, &m); err == nil {
-		db.UpdateProfit(m)
+	options := manufacturing.NewOptions()
+	options.CorporationID = corporationID
+
+	if err := manufacturing.NewManufacturing(nil, int32(typeID), options, &m); err == nil {
+		db.UpdateProfit(corporationID, m)
 	} else {
 		log.Printf("Error while manufacturing %s (%d): %v", m.Product.TypeName, typeID, err)
 	}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MinErrorLimitRemain is the number of remaining ESI errors below which all requests are paused until the error
+// limit is reset. ESI bans clients that exceed the limit.
+const MinErrorLimitRemain = 20
+
+// Budget is the error budget of all requests of the ESI client
+var Budget = NewErrorBudget()
+
+// ErrorBudget keeps track of the ESI error limit, which is shared by all requests of this application
+type ErrorBudget struct {
+	mutex  sync.Mutex
+	remain int
+	reset  time.Time
+}
+
+func NewErrorBudget() *ErrorBudget {
+	return &ErrorBudget{
+		remain: -1,
+	}
+}
+
+// Update updates the error budget with the error limit headers of an ESI response
+func (b *ErrorBudget) Update(httpResponse *http.Response) {
+	if httpResponse == nil {
+		return
+	}
+
+	remain, err := strconv.Atoi(httpResponse.Header.Get("x-esi-error-limit-remain"))
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.Atoi(httpResponse.Header.Get("x-esi-error-limit-reset"))
+	if err != nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.remain = remain
+	b.reset = time.Now().Add(time.Duration(reset) * time.Second)
+
+	if b.remain < MinErrorLimitRemain {
+		log.Warnf("Only %d ESI errors remain, pausing all requests for %d seconds", remain, reset)
+	}
+}
+
+// Status returns the remaining errors, the time of the reset and whether requests are paused
+func (b *ErrorBudget) Status() (remain int, reset *time.Time, paused bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.remain >= 0 {
+		t := b.reset
+		reset = &t
+	}
+
+	return b.remain, reset, b.paused()
+}
+
+func (b *ErrorBudget) paused() bool {
+	return b.remain >= 0 && b.remain < MinErrorLimitRemain && time.Now().Before(b.reset)
+}
+
+// Wait blocks until the error budget allows further requests. It returns false, if the context was cancelled
+// in the meantime.
+func (b *ErrorBudget) Wait(ctx context.Context) bool {
+	for {
+		b.mutex.Lock()
+		paused := b.paused()
+		duration := time.Until(b.reset)
+		b.mutex.Unlock()
+
+		if !paused {
+			return ctx.Err() == nil
+		}
+
+		timer := time.NewTimer(duration)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// budgetTransport gates every request of the ESI client by the error budget and updates the budget with every
+// response, so that concurrent requests, such as pages or the market history of many types, are covered as well
+type budgetTransport struct {
+	budget *ErrorBudget
+	next   http.RoundTripper
+}
+
+func (t *budgetTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if !t.budget.Wait(request.Context()) {
+		return nil, request.Context().Err()
+	}
+
+	response, err := t.next.RoundTrip(request)
+
+	t.budget.Update(response)
+
+	return response, err
+}
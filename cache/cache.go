@@ -18,17 +18,16 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/antihax/goesi/esi"
 
+	"github.com/oxisto/titan/db"
 	"github.com/oxisto/titan/model"
 
 	"github.com/antihax/goesi"
@@ -40,15 +39,19 @@ import (
 )
 
 var cache *redis.Client
-var expiryChannel <-chan *redis.Message
 var log *logrus.Entry
 
 // ESI is the ESI client
 var ESI *esi.APIClient
 
+// RoleDirector is the corporation role that is needed to read all corporation data
+const RoleDirector = "Director"
+
 func init() {
 	log = logrus.WithField("component", "cache")
-	ESI = goesi.NewAPIClient(&http.Client{}, "Titan").ESI
+	ESI = goesi.NewAPIClient(&http.Client{
+		Transport: &budgetTransport{budget: Budget, next: http.DefaultTransport},
+	}, "Titan").ESI
 }
 
 func InitCache(redisAddr string) (err error) {
@@ -63,51 +66,9 @@ func InitCache(redisAddr string) (err error) {
 		return fmt.Errorf("could not connect to cache: %w", status.Err())
 	}
 
-	cache.ConfigSet("notify-keyspace-events", "KEA")
-
-	pubsub := cache.Subscribe("__keyevent@0__:expired")
-
-	_, err = pubsub.Receive()
-	if err != nil {
-		return fmt.Errorf("could not subscribe to expiration events: %w", err)
-	}
-
-	ch := pubsub.Channel()
-
-	go ExpirySubscriber(ch)
-
 	return
 }
 
-func ExpirySubscriber(ch <-chan *redis.Message) {
-	for msg := range ch {
-		key := msg.Payload
-
-		// split
-		rr := strings.SplitN(key, ":", 2)
-
-		if len(rr) != 2 {
-			log.Errorf("Got an invalid key %s", key)
-			continue
-		}
-
-		typ := rr[0]
-		corporationID, err := strconv.Atoi(rr[1])
-
-		if err != nil {
-			continue
-		}
-
-		if typ == "corporation-assets" {
-			assets := model.CorporationAssets{}
-			err := GetCorporationAssets(0, int32(corporationID), &assets)
-			if err != nil {
-				log.Error(err)
-			}
-		}
-	}
-}
-
 type FetchFuncType func(callerID int32, objectID int32, object model.CachedObject) error
 
 func GetCharacter(characterID int32, character *model.Character) error {
@@ -125,11 +86,6 @@ func GetCorporationWallets(callerID int32, corporationID int32, wallets *model.W
 	return GetCachedObject(hashKey, callerID, corporationID, wallets, FetchCorporationWallets)
 }
 
-func GetCorporationAssets(callerID int32, corporationID int32, jobs *model.CorporationAssets) error {
-	hashKey := fmt.Sprintf("corporation-assets:%d", corporationID)
-	return GetCachedObject(hashKey, callerID, corporationID, jobs, FetchCorporationAssets)
-}
-
 func GetAccessToken(characterID int32, accessToken *model.AccessToken) error {
 	hashKey := fmt.Sprintf("accesstoken:%d", characterID)
 	return GetCachedObject(hashKey, characterID, characterID, accessToken, FetchAccessToken)
@@ -150,6 +106,42 @@ func GetAccessTokenForCorporation(corporationID int32, accessToken *model.Access
 	return GetCachedObject(hashKey, characterID, characterID, accessToken, FetchAccessToken)
 }
 
+// GetCorporationAccessTokens returns the links of all corporations to the character of a director, whose
+// access token can be used to fetch corporation data
+func GetCorporationAccessTokens() ([]model.CorporationAccessToken, error) {
+	var (
+		keys   []string
+		page   []string
+		cursor uint64
+		err    error
+	)
+
+	for {
+		if page, cursor, err = cache.Scan(cursor, "corporation-accesstoken:*", 100).Result(); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, page...)
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	tokens := []model.CorporationAccessToken{}
+
+	for _, key := range keys {
+		token := model.CorporationAccessToken{}
+
+		// the key could have been deleted in the meantime
+		if err = ReadCachedObject(key, &token); err == nil && token.CorporationID != 0 {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens, nil
+}
+
 func GetCachedObject(hashKey string, callerID int32, objectID int32, object model.CachedObject, funcType FetchFuncType) (err error) {
 	exists, err := cache.Exists(hashKey).Result()
 	if err != nil {
@@ -374,50 +366,6 @@ func FetchCorporationWallets(callerID int32, corporationID int32, object model.C
 	return nil
 }
 
-func FetchCorporationAssets(callerID int32, corporationID int32, object model.CachedObject) error {
-	assets, ok := object.(*model.CorporationAssets)
-	if !ok {
-		return errors.New("passing invalid type to FetchCorporationAssets function")
-	}
-
-	// find access token for corporation
-	accessToken := model.AccessToken{}
-	err := GetAccessTokenForCorporation(corporationID, &accessToken)
-	if err != nil {
-		return err
-	}
-
-	response, httpResponse, err := ESI.AssetsApi.GetCorporationsCorporationIdAssets(context.WithValue(context.Background(), goesi.ContextAccessToken, accessToken.Token), corporationID, nil)
-	if err != nil {
-		return err
-	}
-
-	t, err := time.Parse(time.RFC1123, httpResponse.Header.Get("Expires"))
-	if err != nil {
-		return err
-	}
-
-	assets.SetExpire(&t)
-
-	assets.CorporationID = corporationID
-	assets.Assets = map[string]model.Asset{}
-
-	for _, v := range response {
-		asset := model.Asset{
-			IsSingleton:  v.IsSingleton,
-			TypeID:       v.TypeId,
-			ItemID:       v.ItemId,
-			LocationID:   v.LocationId,
-			LocationType: v.LocationType,
-			LocationFlag: v.LocationFlag,
-		}
-
-		assets.Assets[strconv.Itoa(int(asset.ItemID))] = asset
-	}
-
-	return nil
-}
-
 func FetchCharacter(callerID int32, characterID int32, object model.CachedObject) error {
 	character, ok := object.(*model.Character)
 	if !ok {
@@ -469,15 +417,43 @@ func FetchCharacter(callerID int32, characterID int32, object model.CachedObject
 		character.Skills[strconv.Itoa(int(s.SkillID))] = s
 	}
 
-	// set this character as corporation access token
+	// roles can only be read with the token of the character itself
+	if callerID == characterID {
+		updateCorporationAccessToken(character, &accessToken)
+	}
+
+	return nil
+}
+
+// updateCorporationAccessToken sets the character as corporation access token, if it is a director of its
+// corporation. Only directors can read corporation data, such as wallets or industry jobs. If the character
+// was the corporation access token but is no longer a director, the corporation access token is removed.
+func updateCorporationAccessToken(character *model.Character, accessToken *model.AccessToken) {
+	roles, _, err := ESI.CharacterApi.GetCharactersCharacterIdRoles(context.WithValue(context.Background(), goesi.ContextAccessToken, accessToken.Token), character.CharacterID, nil)
+	if err != nil {
+		// older tokens might not have the necessary scope, so we leave everything as it is
+		log.Warnf("Could not retrieve roles of character %d: %v", character.CharacterID, err)
+		return
+	}
+
 	corporationAccessToken := model.CorporationAccessToken{
 		CorporationID: character.CorporationID,
-		CharacterID:   characterID,
+		CharacterID:   character.CharacterID,
 	}
 
-	WriteCachedObject(&corporationAccessToken)
+	for _, role := range roles.Roles {
+		if role == RoleDirector {
+			WriteCachedObject(&corporationAccessToken)
+			return
+		}
+	}
 
-	return nil
+	existing := model.CorporationAccessToken{}
+	if err = ReadCachedObject(corporationAccessToken.HashKey(), &existing); err == nil && existing.CharacterID == character.CharacterID {
+		log.Infof("Character %d is no longer a director of corporation %d", character.CharacterID, character.CorporationID)
+
+		cache.Del(corporationAccessToken.HashKey())
+	}
 }
 
 func FetchSystemCostIndices() (indices map[int32]model.CachedObject, err error) {
@@ -496,6 +472,7 @@ func FetchSystemCostIndices() (indices map[int32]model.CachedObject, err error)
 	for _, v := range response {
 		index := model.SystemCostIndex{
 			SolarSystemID: v.SolarSystemId,
+			ActivityCost:  map[string]float32{},
 		}
 
 		for _, w := range v.CostIndices {
@@ -597,8 +574,10 @@ func GetSystemCostIndex(solarSystemID int32) (index *model.SystemCostIndex, err
 		return
 	}
 
-	// we can only fetch prices from ESI in bulk
-	indices, err = FetchSystemCostIndices()
+	// we can only fetch cost indices from ESI in bulk
+	if indices, err = FetchSystemCostIndices(); err != nil {
+		return nil, err
+	}
 
 	WriteCachedObjects(indices)
 
@@ -643,7 +622,10 @@ func GetMarketPrice(typeID int32) (price *model.MarketPrice, err error) {
 	return
 }
 
-func GetPrices(regionID int, types []int32) (prices map[int32]model.Price, err error) {
+// GetPrices returns the prices of the specified types at a trade hub. Prices that are not cached are fetched
+// from the configured price source and stored as a snapshot, so that the price history of every hub that is
+// used is kept.
+func GetPrices(hub model.TradeHub, types []int32) (prices map[int32]model.Price, err error) {
 	prices = make(map[int32]model.Price)
 
 	typesToFetch := []int32{}
@@ -652,7 +634,7 @@ func GetPrices(regionID int, types []int32) (prices map[int32]model.Price, err e
 		var (
 			cached  int64
 			price   model.Price
-			hashKey = fmt.Sprintf("price:%d", typeID)
+			hashKey = model.PriceHashKey(hub.LocationID(), typeID)
 		)
 
 		// check, if prices are somehow cached
@@ -688,53 +670,35 @@ func GetPrices(regionID int, types []int32) (prices map[int32]model.Price, err e
 			chunkEnd = len(typesToFetch)
 		}
 
-		// deliberately ignore errors here, because fuzzwork json objects are sometimes not properly formatted
-		results, _ := FetchPrices(regionID, typesToFetch[chunkStart:chunkEnd])
+		var results map[int32]model.Price
+		if results, err = Prices.FetchPrices(hub, typesToFetch[chunkStart:chunkEnd]); err != nil {
+			return nil, fmt.Errorf("could not fetch prices from %s: %w", Prices.Name(), err)
+		}
 
 		objects := map[int32]model.CachedObject{}
-		for k, p := range results {
+		snapshot := map[int32]model.Price{}
+		for typeID, p := range results {
 			price := p
 
-			typeID, err := strconv.Atoi(k)
 			expireDate := time.Now().Add(time.Hour * time.Duration(1))
-
-			if err != nil {
-				return nil, err
-			}
-
-			price.TypeID = int32(typeID)
+			price.LocationID = hub.LocationID()
 			price.SetExpire(&expireDate)
 
 			// add to cached objects
-			objects[price.TypeID] = &price
+			objects[typeID] = &price
 
-			prices[int32(typeID)] = price
+			prices[typeID] = price
+			snapshot[typeID] = price
 		}
 
 		// update cache
 		WriteCachedObjects(objects)
-	}
 
-	return
-}
-
-func FetchPrices(regionID int, types []int32) (prices map[string]model.Price, err error) {
-	typesParam := []string{}
-
-	for _, typeID := range types {
-		typesParam = append(typesParam, strconv.Itoa(int(typeID)))
-	}
-
-	log.Infof("Requesting %d types from fuzzwork", len(types))
-
-	url := "https://market.fuzzwork.co.uk/aggregates/?region=" + strconv.Itoa(regionID) + "&types=" + strings.Join(typesParam, ",")
-
-	res, err := http.Get(url)
-	if err != nil {
-		return nil, err
+		if err = db.InsertPrices(snapshot, time.Now()); err != nil {
+			log.Errorf("Could not store price history of %s: %v", hub.Name, err)
+			err = nil
+		}
 	}
 
-	err = json.NewDecoder(res.Body).Decode(&prices)
-
 	return
 }
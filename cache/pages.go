@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// esiPageConcurrency limits the number of pages of a single resource that are requested at the same time
+const esiPageConcurrency = 5
+
+// PageFunc fetches a single page of a paginated ESI resource. Pages start at 1.
+type PageFunc func(page int32) (*http.Response, error)
+
+// FetchPages fetches all pages of a paginated ESI resource. The first page is requested on its own,
+// because only its X-Pages header tells us how many pages there are. The remaining pages are then
+// requested concurrently. The responses are returned in page order; if any page fails, an error is
+// returned and the responses should be discarded.
+func FetchPages(ctx context.Context, fetchPage PageFunc) ([]*http.Response, error) {
+	first, err := fetchPage(1)
+	if err != nil {
+		return []*http.Response{first}, err
+	}
+
+	responses := make([]*http.Response, NumPages(first))
+	responses[0] = first
+
+	var (
+		mutex     sync.Mutex
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, esiPageConcurrency)
+		errs      []error
+	)
+
+	for page := 2; page <= len(responses); page++ {
+		// do not start any more requests, if we are shutting down
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(page int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			response, err := fetchPage(int32(page))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not fetch page %d: %w", page, err))
+			}
+
+			responses[page-1] = response
+		}(page)
+	}
+
+	wg.Wait()
+
+	if err = ctx.Err(); err != nil {
+		return responses, err
+	}
+
+	if len(errs) > 0 {
+		return responses, fmt.Errorf("could not fetch %d of %d pages: %w", len(errs), len(responses), errs[0])
+	}
+
+	return responses, nil
+}
+
+// NumPages returns the number of pages announced in the X-Pages header of the response. Resources
+// which are not paginated do not send this header, so they consist of a single page.
+func NumPages(response *http.Response) int {
+	pages, err := strconv.Atoi(response.Header.Get("x-pages"))
+	if err != nil || pages < 1 {
+		return 1
+	}
+
+	return pages
+}
@@ -0,0 +1,350 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/oxisto/titan/model"
+)
+
+const (
+	PriceSourceFuzzwork = "fuzzwork"
+	PriceSourceESI      = "esi"
+	PriceSourceFile     = "file"
+)
+
+// PricePercentile is the share of the order volume that is used to calculate the percentile price
+const PricePercentile = 0.05
+
+// esiOrderConcurrency limits the number of order book requests that are issued to ESI at the same time
+const esiOrderConcurrency = 10
+
+// PriceSource retrieves aggregated buy and sell prices of types within a region. Implementations must return an
+// error instead of empty prices, if the prices cannot be retrieved.
+type PriceSource interface {
+	// Name is the name of the price source, which is used in log and error messages
+	Name() string
+
+	// FetchPrices fetches the prices of the specified types at a trade hub. The returned map is keyed by type ID.
+	FetchPrices(hub model.TradeHub, types []int32) (map[int32]model.Price, error)
+}
+
+// Prices is the price source that is used for all prices that are not cached. It is chosen by the server
+// configuration and defaults to Fuzzwork.
+var Prices PriceSource = NewFuzzworkPriceSource()
+
+// NewPriceSource creates the price source with the specified name. The file is only used by the file price source.
+func NewPriceSource(name string, file string) (PriceSource, error) {
+	switch name {
+	case PriceSourceFuzzwork:
+		return NewFuzzworkPriceSource(), nil
+	case PriceSourceESI:
+		return NewESIPriceSource(), nil
+	case PriceSourceFile:
+		return NewFilePriceSource(file)
+	default:
+		return nil, fmt.Errorf("unknown price source %q", name)
+	}
+}
+
+type fuzzworkPriceSource struct{}
+
+// NewFuzzworkPriceSource returns a price source that uses the market aggregates of market.fuzzwork.co.uk
+func NewFuzzworkPriceSource() PriceSource {
+	return &fuzzworkPriceSource{}
+}
+
+func (s fuzzworkPriceSource) Name() string {
+	return PriceSourceFuzzwork
+}
+
+func (s fuzzworkPriceSource) FetchPrices(hub model.TradeHub, types []int32) (prices map[int32]model.Price, err error) {
+	typesParam := []string{}
+
+	for _, typeID := range types {
+		typesParam = append(typesParam, strconv.Itoa(int(typeID)))
+	}
+
+	log.Infof("Requesting %d types from fuzzwork", len(types))
+
+	// fuzzwork aggregates either a single station or a whole region
+	location := "region=" + strconv.Itoa(int(hub.RegionID))
+	if hub.StationID != 0 {
+		location = "station=" + strconv.FormatInt(hub.StationID, 10)
+	}
+
+	url := "https://market.fuzzwork.co.uk/aggregates/?" + location + "&types=" + strings.Join(typesParam, ",")
+
+	res, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fuzzwork returned status %d", res.StatusCode)
+	}
+
+	var results map[string]model.Price
+	if err = json.NewDecoder(res.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("could not decode fuzzwork prices: %w", err)
+	}
+
+	return pricesByTypeID(results)
+}
+
+type esiPriceSource struct{}
+
+// NewESIPriceSource returns a price source that fetches the regional order books from ESI and aggregates
+// them locally, in the same way Fuzzwork does
+func NewESIPriceSource() PriceSource {
+	return &esiPriceSource{}
+}
+
+func (s esiPriceSource) Name() string {
+	return PriceSourceESI
+}
+
+func (s esiPriceSource) FetchPrices(hub model.TradeHub, types []int32) (prices map[int32]model.Price, err error) {
+	var (
+		mutex     sync.Mutex
+		wg        sync.WaitGroup
+		semaphore = make(chan struct{}, esiOrderConcurrency)
+		errs      []error
+	)
+
+	log.Infof("Requesting order books of %d types from ESI", len(types))
+
+	prices = map[int32]model.Price{}
+
+	for _, typeID := range types {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(typeID int32) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			orders, err := fetchOrders(hub, typeID)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not fetch orders of type %d: %w", typeID, err))
+				return
+			}
+
+			prices[typeID] = aggregateOrders(typeID, orders)
+		}(typeID)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%d of %d order books failed, first error: %w", len(errs), len(types), errs[0])
+	}
+
+	return prices, nil
+}
+
+// fetchOrders fetches all pages of the order book of one type in the region of a trade hub. If the trade hub
+// has a station, only the orders in this station are returned.
+func fetchOrders(hub model.TradeHub, typeID int32) (orders []esi.GetMarketsRegionIdOrders200Ok, err error) {
+	pages := 1
+
+	for page := 1; page <= pages; page++ {
+		options := esi.GetMarketsRegionIdOrdersOpts{
+			TypeId: optional.NewInt32(typeID),
+			Page:   optional.NewInt32(int32(page)),
+		}
+
+		response, httpResponse, err := ESI.MarketApi.GetMarketsRegionIdOrders(context.Background(), "all", hub.RegionID, &options)
+		if err != nil {
+			return nil, err
+		}
+
+		if p, err := strconv.Atoi(httpResponse.Header.Get("x-pages")); err == nil {
+			pages = p
+		}
+
+		for _, order := range response {
+			if hub.StationID == 0 || order.LocationId == hub.StationID {
+				orders = append(orders, order)
+			}
+		}
+	}
+
+	return orders, nil
+}
+
+// aggregateOrders calculates the buy and sell price data of an order book
+func aggregateOrders(typeID int32, orders []esi.GetMarketsRegionIdOrders200Ok) model.Price {
+	var buy, sell []esi.GetMarketsRegionIdOrders200Ok
+
+	for _, order := range orders {
+		if order.IsBuyOrder {
+			buy = append(buy, order)
+		} else {
+			sell = append(sell, order)
+		}
+	}
+
+	// the best buy orders are the highest, the best sell orders are the lowest
+	sort.Slice(buy, func(i, j int) bool { return buy[i].Price > buy[j].Price })
+	sort.Slice(sell, func(i, j int) bool { return sell[i].Price < sell[j].Price })
+
+	return model.Price{
+		TypeID: typeID,
+		Buy:    aggregatePriceData(buy),
+		Sell:   aggregatePriceData(sell),
+	}
+}
+
+// aggregatePriceData calculates the price data of orders, which need to be sorted from the best to the worst price
+func aggregatePriceData(orders []esi.GetMarketsRegionIdOrders200Ok) (data model.PriceData) {
+	if len(orders) == 0 {
+		return data
+	}
+
+	var total, volume float64
+
+	data.OrderCount = len(orders)
+	data.Min = math.MaxFloat64
+
+	for _, order := range orders {
+		data.Volume += int(order.VolumeRemain)
+		data.Min = math.Min(data.Min, order.Price)
+		data.Max = math.Max(data.Max, order.Price)
+
+		total += order.Price * float64(order.VolumeRemain)
+	}
+
+	volume = float64(data.Volume)
+	if volume == 0 {
+		return data
+	}
+
+	data.WeightedAverage = total / volume
+
+	var variance, percentileTotal, percentileVolume, cumulative float64
+	median := volume / 2
+	medianFound := false
+
+	for _, order := range orders {
+		v := float64(order.VolumeRemain)
+
+		variance += v * math.Pow(order.Price-data.WeightedAverage, 2)
+
+		// the percentile is the average price of the best orders that make up the percentile volume
+		if percentileVolume < volume*PricePercentile {
+			part := math.Min(v, volume*PricePercentile-percentileVolume)
+			percentileVolume += part
+			percentileTotal += part * order.Price
+		}
+
+		cumulative += v
+		if !medianFound && cumulative >= median {
+			data.Median = order.Price
+			medianFound = true
+		}
+	}
+
+	data.StdDev = math.Sqrt(variance / volume)
+	data.Percentile = percentileTotal / percentileVolume
+
+	return data
+}
+
+type filePriceSource struct {
+	file   string
+	prices map[int32]model.Price
+}
+
+// NewFilePriceSource returns a price source that reads all prices from a static JSON file. The file uses the
+// same format as the Fuzzwork aggregates, so that a Fuzzwork response can be used directly. This is useful
+// for testing and working offline. The file only contains the prices of a single market, so the trade hub
+// is ignored.
+func NewFilePriceSource(file string) (PriceSource, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open price file: %w", err)
+	}
+	defer f.Close()
+
+	var results map[string]model.Price
+	if err = json.NewDecoder(f).Decode(&results); err != nil {
+		return nil, fmt.Errorf("could not decode price file: %w", err)
+	}
+
+	source := &filePriceSource{file: file}
+	if source.prices, err = pricesByTypeID(results); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func (s filePriceSource) Name() string {
+	return PriceSourceFile
+}
+
+func (s filePriceSource) FetchPrices(hub model.TradeHub, types []int32) (prices map[int32]model.Price, err error) {
+	prices = map[int32]model.Price{}
+
+	for _, typeID := range types {
+		price, ok := s.prices[typeID]
+		if !ok {
+			return nil, fmt.Errorf("price file %s does not contain type %d", s.file, typeID)
+		}
+
+		prices[typeID] = price
+	}
+
+	return prices, nil
+}
+
+// pricesByTypeID converts prices keyed by a type ID string, as used by Fuzzwork, into prices keyed by type ID
+func pricesByTypeID(results map[string]model.Price) (prices map[int32]model.Price, err error) {
+	prices = map[int32]model.Price{}
+
+	for k, price := range results {
+		var typeID int
+		if typeID, err = strconv.Atoi(k); err != nil {
+			return nil, fmt.Errorf("invalid type ID %q: %w", k, err)
+		}
+
+		price.TypeID = int32(typeID)
+		prices[price.TypeID] = price
+	}
+
+	return prices, nil
+}
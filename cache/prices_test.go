@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/antihax/goesi/esi"
+	"github.com/oxisto/titan/model"
+)
+
+func TestAggregateOrders(t *testing.T) {
+	const typeID = 34
+
+	tests := []struct {
+		name   string
+		orders []esi.GetMarketsRegionIdOrders200Ok
+		want   model.Price
+	}{
+		{
+			name: "buy and sell orders",
+			orders: []esi.GetMarketsRegionIdOrders200Ok{
+				{IsBuyOrder: true, Price: 9, VolumeRemain: 100},
+				{IsBuyOrder: false, Price: 7, VolumeRemain: 30},
+				{IsBuyOrder: true, Price: 10, VolumeRemain: 100},
+				{IsBuyOrder: false, Price: 5, VolumeRemain: 10},
+			},
+			want: model.Price{
+				TypeID: typeID,
+				Buy: model.PriceData{
+					WeightedAverage: 9.5,
+					Max:             10,
+					Min:             9,
+					StdDev:          0.5,
+					Median:          10,
+					Volume:          200,
+					OrderCount:      2,
+					Percentile:      10,
+				},
+				Sell: model.PriceData{
+					WeightedAverage: 6.5,
+					Max:             7,
+					Min:             5,
+					StdDev:          math.Sqrt(0.75),
+					Median:          7,
+					Volume:          40,
+					OrderCount:      2,
+					Percentile:      5,
+				},
+			},
+		},
+		{
+			name: "only sell orders",
+			orders: []esi.GetMarketsRegionIdOrders200Ok{
+				{Price: 5, VolumeRemain: 10},
+			},
+			want: model.Price{
+				TypeID: typeID,
+				Sell: model.PriceData{
+					WeightedAverage: 5,
+					Max:             5,
+					Min:             5,
+					Median:          5,
+					Volume:          10,
+					OrderCount:      1,
+					Percentile:      5,
+				},
+			},
+		},
+		{
+			name: "no remaining volume",
+			orders: []esi.GetMarketsRegionIdOrders200Ok{
+				{Price: 5},
+			},
+			want: model.Price{
+				TypeID: typeID,
+				Sell: model.PriceData{
+					Max:        5,
+					Min:        5,
+					OrderCount: 1,
+				},
+			},
+		},
+		{
+			name: "no orders",
+			want: model.Price{TypeID: typeID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aggregateOrders(typeID, tt.orders); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aggregateOrders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
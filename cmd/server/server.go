@@ -17,14 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/oxisto/titan"
 	"github.com/oxisto/titan/cache"
 	"github.com/oxisto/titan/datafetch"
 	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
+	"github.com/oxisto/titan/model"
 	"github.com/oxisto/titan/routes"
 
 	log "github.com/sirupsen/logrus"
@@ -36,8 +41,13 @@ const (
 	RedisFlag              = "redis"
 	PostgresFlag           = "postgres"
 	ListenFlag             = "listen"
+	CorporationIDsFlag     = "corporationIDs"
 	CorporationIDFlag      = "corporationID"
 	CacheManufacturingFlag = "cache.manufacturing"
+	SolarSystemIDFlag      = "solarSystemID"
+	PriceSourceFlag        = "prices.source"
+	PriceFileFlag          = "prices.file"
+	RepairJournalFlag      = "repair.journal"
 	EveClientID            = "eve.clientID"
 	EveSecretKey           = "eve.secretKey"
 	EveRedirectURI         = "eve.redirectURI"
@@ -45,8 +55,10 @@ const (
 	DefaultRedis              = "localhost:6379"
 	DefaultPostgres           = "localhost"
 	DefaultListen             = ":4300"
-	DefaultCorporationID      = 0
 	DefaultCacheManufacturing = "true"
+	DefaultCorporationID      = 0
+	DefaultSolarSystemID      = model.JitaSystemID
+	DefaultPriceSource        = cache.PriceSourceFuzzwork
 	DefaultEmpty              = ""
 
 	EnvPrefix = "TITAN"
@@ -65,7 +77,13 @@ func init() {
 	serverCmd.Flags().String(ListenFlag, DefaultListen, "Host and port to listen to")
 	serverCmd.Flags().String(RedisFlag, DefaultRedis, "Host and port of redis server")
 	serverCmd.Flags().String(PostgresFlag, DefaultPostgres, "Connection string for PostgreSQL")
-	serverCmd.Flags().Int32(CorporationIDFlag, DefaultCorporationID, "If specified, limits access to this corporation ID")
+	serverCmd.Flags().IntSlice(CorporationIDsFlag, nil, "If specified, limits access and data fetching to these corporation IDs. Otherwise, every corporation whose director has logged in is tracked")
+	serverCmd.Flags().Int32(CorporationIDFlag, DefaultCorporationID, "If specified, limits access and data fetching to this corporation ID")
+	serverCmd.Flags().MarkDeprecated(CorporationIDFlag, "use --"+CorporationIDsFlag+" instead")
+	serverCmd.Flags().Int32(SolarSystemIDFlag, DefaultSolarSystemID, "The solar system whose cost indices are used for industry calculations by default")
+	serverCmd.Flags().String(PriceSourceFlag, DefaultPriceSource, "The source of market prices, either fuzzwork, esi or file")
+	serverCmd.Flags().String(PriceFileFlag, DefaultEmpty, "The JSON file containing market prices, if the file price source is used")
+	serverCmd.Flags().Bool(RepairJournalFlag, false, "Re-fetches the wallet journal of every tracked corporation once at startup and corrects the division of stored entries")
 	serverCmd.Flags().String(EveClientID, DefaultEmpty, "The EVE SSO Client ID")
 	serverCmd.Flags().String(EveSecretKey, DefaultEmpty, "The EVE SSO Secret Key")
 	serverCmd.Flags().String(EveRedirectURI, DefaultEmpty, "The EVE SSO Redirect URI")
@@ -76,8 +94,13 @@ func init() {
 	viper.BindPFlag(ListenFlag, serverCmd.Flags().Lookup(ListenFlag))
 	viper.BindPFlag(RedisFlag, serverCmd.Flags().Lookup(RedisFlag))
 	viper.BindPFlag(PostgresFlag, serverCmd.Flags().Lookup(PostgresFlag))
+	viper.BindPFlag(CorporationIDsFlag, serverCmd.Flags().Lookup(CorporationIDsFlag))
 	viper.BindPFlag(CorporationIDFlag, serverCmd.Flags().Lookup(CorporationIDFlag))
 	viper.BindPFlag(CacheManufacturingFlag, serverCmd.Flags().Lookup(CacheManufacturingFlag))
+	viper.BindPFlag(SolarSystemIDFlag, serverCmd.Flags().Lookup(SolarSystemIDFlag))
+	viper.BindPFlag(PriceSourceFlag, serverCmd.Flags().Lookup(PriceSourceFlag))
+	viper.BindPFlag(PriceFileFlag, serverCmd.Flags().Lookup(PriceFileFlag))
+	viper.BindPFlag(RepairJournalFlag, serverCmd.Flags().Lookup(RepairJournalFlag))
 	viper.BindPFlag(EveClientID, serverCmd.Flags().Lookup(EveClientID))
 	viper.BindPFlag(EveSecretKey, serverCmd.Flags().Lookup(EveSecretKey))
 	viper.BindPFlag(EveRedirectURI, serverCmd.Flags().Lookup(EveRedirectURI))
@@ -117,35 +140,77 @@ func doCmd(cmd *cobra.Command, args []string) {
 
 	db.InitPostgreSQL(viper.GetString(PostgresFlag))
 
+	prices, err := cache.NewPriceSource(viper.GetString(PriceSourceFlag), viper.GetString(PriceFileFlag))
+	if err != nil {
+		log.Errorf("Could not initialize price source: %s", err)
+		return
+	}
+
+	cache.Prices = prices
+
+	manufacturing.DefaultSolarSystemID = int32(viper.GetInt(SolarSystemIDFlag))
+
+	corporationIDs := []int32{}
+	for _, corporationID := range viper.GetIntSlice(CorporationIDsFlag) {
+		corporationIDs = append(corporationIDs, int32(corporationID))
+	}
+
+	// the deprecated single corporation still restricts access, so that existing deployments stay closed
+	if corporationID := int32(viper.GetInt(CorporationIDFlag)); corporationID != DefaultCorporationID {
+		log.Warnf("The %s option is deprecated, please use %s instead", CorporationIDFlag, CorporationIDsFlag)
+		corporationIDs = append(corporationIDs, corporationID)
+	}
+
 	app := titan.App{
 		CacheManufacturing: viper.GetBool(CacheManufacturingFlag),
-		CorporationID:      int32(viper.GetInt(CorporationIDFlag)),
 	}
 
 	app.ImportSDE()
 
 	go app.ServerLoop()
 
-	var division int32
+	ctx, cancel := context.WithCancel(context.Background())
 
-	for division = 1; division <= 3; division++ {
-		transactionService := datafetch.NewFetchService(app.CorporationID, datafetch.NewTransactionFetcher(division))
-		go transactionService.StartLoop()
+	scheduler := datafetch.NewScheduler()
 
-		journalService := datafetch.NewFetchService(app.CorporationID, datafetch.NewJournalFetcher(division))
-		go journalService.StartLoop()
-	}
+	// the market history is public and shared by all corporations
+	scheduler.Add(datafetch.NewFetchService(0, datafetch.NewMarketHistoryFetcher()))
+
+	scheduler.Start(ctx)
 
-	jobsService := datafetch.NewFetchService(app.CorporationID, datafetch.NewIndustryJobsFetcher())
-	go jobsService.StartLoop()
+	// corporations are added to the scheduler, once one of their directors has logged in
+	tracker := datafetch.NewCorporationTracker(scheduler, corporationIDs)
+	tracker.RepairJournal = viper.GetBool(RepairJournalFlag)
+
+	go tracker.Run(ctx)
 
 	//go app.TransactionLoop()
 	//go ContractsLoop()
 
-	router := routes.NewRouter(int32(viper.GetInt(CorporationIDFlag)))
-	err := http.ListenAndServe(viper.GetString(ListenFlag), router)
+	router := routes.NewRouter(corporationIDs, scheduler)
+	server := &http.Server{
+		Addr:    viper.GetString(ListenFlag),
+		Handler: router,
+	}
+
+	// shut down gracefully, so that no fetcher is interrupted in the middle of writing to the database
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+		<-signals
+
+		log.Info("Shutting down server...")
+
+		cancel()
+		server.Shutdown(context.Background())
+	}()
+
+	if err = server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Errorf("An error occured: %v", err)
+	}
 
-	log.Errorf("An error occured: %v", err)
+	scheduler.Wait()
 }
 
 func main() {
@@ -0,0 +1,139 @@
+package datafetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/antihax/goesi"
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+	"github.com/sirupsen/logrus"
+)
+
+type assetsFetcher struct {
+	metadata
+}
+
+func NewAssetsFetcher() DataFetcher {
+	return &assetsFetcher{
+		metadata: metadata{
+			dataType:     "assets",
+			maxCacheTime: time.Hour,
+		},
+	}
+}
+
+func (f *assetsFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
+	// the assets are replaced as a whole, so we need all pages as soon as one of them changed
+	pages, err := fetchPages(ctx, f, true, func(page int32, etag string) (interface{}, *http.Response, error) {
+		var options esi.GetCorporationsCorporationIdAssetsOpts
+		options.Page = optional.NewInt32(page)
+
+		if etag != "" {
+			options.IfNoneMatch = optional.NewString(etag)
+		}
+
+		return cache.ESI.AssetsApi.GetCorporationsCorporationIdAssets(
+			context.WithValue(ctx,
+				goesi.ContextAccessToken,
+				ctx.accessToken.Token),
+			ctx.corporationID,
+			&options)
+	})
+	if err != nil {
+		return pages.First(), err
+	}
+
+	httpResponse := pages.First()
+
+	limitFields := logrus.Fields{
+		"esi-err-remain": httpResponse.Header.Get("x-esi-error-limit-remain"),
+		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
+		"pages":          len(pages.responses),
+	}
+
+	if !pages.Modified() {
+		ctx.log.WithFields(limitFields).Info("Assets have not changed")
+
+		return httpResponse, nil
+	}
+
+	assets := []model.Asset{}
+
+	for _, result := range pages.results {
+		response, _ := result.([]esi.GetCorporationsCorporationIdAssets200Ok)
+
+		for _, v := range response {
+			assets = append(assets, model.Asset{
+				ItemID:        v.ItemId,
+				CorporationID: ctx.corporationID,
+				TypeID:        v.TypeId,
+				Quantity:      v.Quantity,
+				IsSingleton:   v.IsSingleton,
+				LocationID:    v.LocationId,
+				LocationFlag:  v.LocationFlag,
+				LocationType:  v.LocationType,
+			})
+		}
+	}
+
+	ctx.log.WithFields(limitFields).Infof("Retrieved %d assets", len(assets))
+
+	// containers and offices are assets themselves, so we need all assets to know where an asset actually is
+	model.ResolveAssetLocations(assets)
+
+	if err = db.ReplaceCorporationAssets(ctx.corporationID, assets); err != nil {
+		return httpResponse, fmt.Errorf("could not update assets: %w", err)
+	}
+
+	pages.saveETags(ctx)
+
+	f.fetchStructures(ctx)
+
+	return httpResponse, nil
+}
+
+// fetchStructures retrieves the names of all structures, in which assets are stored, but which we do not know yet
+func (f *assetsFetcher) fetchStructures(ctx FetchContext) {
+	structureIDs, err := db.GetUnknownStructureIDs(ctx.corporationID)
+	if err != nil {
+		ctx.log.Errorf("Could not retrieve unknown structures: %v", err)
+		return
+	}
+
+	for _, structureID := range structureIDs {
+		structure := model.Structure{
+			StructureID: structureID,
+		}
+
+		response, httpResponse, err := cache.ESI.UniverseApi.GetUniverseStructuresStructureId(
+			context.WithValue(ctx,
+				goesi.ContextAccessToken,
+				ctx.accessToken.Token),
+			structureID,
+			nil)
+		if err != nil && httpResponse != nil && httpResponse.StatusCode == http.StatusForbidden {
+			// we have no docking access anymore. Errors count against the ESI error limit, so we remember
+			// the structure under its ID instead of asking again every time
+			ctx.log.Debugf("No access to structure %d", structureID)
+
+			structure.Name = fmt.Sprintf("Structure %d", structureID)
+		} else if err != nil {
+			ctx.log.Errorf("Could not retrieve structure %d: %v", structureID, err)
+			continue
+		} else {
+			structure.Name = response.Name
+			structure.SolarSystemID = response.SolarSystemId
+			structure.TypeID = response.TypeId
+		}
+
+		if err = db.UpdateStructure(structure); err != nil {
+			ctx.log.Errorf("Could not update structure %d: %v", structureID, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package datafetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/antihax/goesi"
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+	"github.com/sirupsen/logrus"
+)
+
+type blueprintsFetcher struct {
+	metadata
+}
+
+func NewBlueprintsFetcher() DataFetcher {
+	return &blueprintsFetcher{
+		metadata: metadata{
+			dataType:     "blueprints",
+			maxCacheTime: time.Hour,
+		},
+	}
+}
+
+func (f *blueprintsFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
+	// the blueprints are replaced as a whole, so we need all pages as soon as one of them changed
+	pages, err := fetchPages(ctx, f, true, func(page int32, etag string) (interface{}, *http.Response, error) {
+		var options esi.GetCorporationsCorporationIdBlueprintsOpts
+		options.Page = optional.NewInt32(page)
+
+		if etag != "" {
+			options.IfNoneMatch = optional.NewString(etag)
+		}
+
+		return cache.ESI.CorporationApi.GetCorporationsCorporationIdBlueprints(
+			context.WithValue(ctx,
+				goesi.ContextAccessToken,
+				ctx.accessToken.Token),
+			ctx.corporationID,
+			&options)
+	})
+	if err != nil {
+		return pages.First(), err
+	}
+
+	httpResponse := pages.First()
+
+	limitFields := logrus.Fields{
+		"esi-err-remain": httpResponse.Header.Get("x-esi-error-limit-remain"),
+		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
+		"pages":          len(pages.responses),
+	}
+
+	if !pages.Modified() {
+		ctx.log.WithFields(limitFields).Info("Blueprints have not changed")
+
+		return httpResponse, nil
+	}
+
+	var response []esi.GetCorporationsCorporationIdBlueprints200Ok
+	for _, result := range pages.results {
+		page, _ := result.([]esi.GetCorporationsCorporationIdBlueprints200Ok)
+
+		response = append(response, page...)
+	}
+
+	ctx.log.WithFields(limitFields).Infof("Retrieved %d blueprints", len(response))
+
+	blueprints := []model.CorporationBlueprint{}
+	for _, b := range response {
+		blueprints = append(blueprints, model.CorporationBlueprint{
+			ItemID:             b.ItemId,
+			CorporationID:      ctx.corporationID,
+			TypeID:             b.TypeId,
+			LocationID:         b.LocationId,
+			LocationFlag:       b.LocationFlag,
+			Quantity:           b.Quantity,
+			MaterialEfficiency: b.MaterialEfficiency,
+			TimeEfficiency:     b.TimeEfficiency,
+			Runs:               b.Runs,
+		})
+	}
+
+	// the blueprints are replaced as a whole, otherwise we would keep blueprints that no longer exist
+	if err = db.ReplaceCorporationBlueprints(ctx.corporationID, blueprints); err != nil {
+		return httpResponse, fmt.Errorf("could not update blueprints: %w", err)
+	}
+
+	pages.saveETags(ctx)
+
+	return httpResponse, nil
+}
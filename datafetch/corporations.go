@@ -0,0 +1,123 @@
+package datafetch
+
+import (
+	"context"
+	"time"
+
+	"github.com/oxisto/titan/cache"
+)
+
+const (
+	// DiscoveryInterval is the time between two checks for new or removed corporations
+	DiscoveryInterval = time.Minute
+
+	// WalletDivisions is the number of wallet divisions whose transactions and journal are fetched
+	WalletDivisions = 3
+)
+
+// NewCorporationServices creates all fetch services that are run for a single corporation
+func NewCorporationServices(corporationID int32) []*FetchService {
+	var division int32
+
+	services := []*FetchService{}
+
+	for division = 1; division <= WalletDivisions; division++ {
+		services = append(services,
+			NewFetchService(corporationID, NewTransactionFetcher(division)),
+			NewFetchService(corporationID, NewJournalFetcher(division)))
+	}
+
+	services = append(services,
+		NewFetchService(corporationID, NewWalletsFetcher()),
+		NewFetchService(corporationID, NewIndustryJobsFetcher()),
+		NewFetchService(corporationID, NewBlueprintsFetcher()),
+		NewFetchService(corporationID, NewAssetsFetcher()))
+
+	return services
+}
+
+// CorporationTracker discovers corporations, for which a director has logged in, and starts their fetch
+// services in the scheduler. If a corporation loses its director access token, its services are stopped.
+type CorporationTracker struct {
+	// RepairJournal specifies, whether the journal of every discovered corporation is repaired once
+	RepairJournal bool
+
+	scheduler *Scheduler
+	allowed   map[int32]bool
+}
+
+// NewCorporationTracker creates a new tracker. If allowed corporation IDs are specified, all other corporations
+// are ignored.
+func NewCorporationTracker(scheduler *Scheduler, allowedCorporationIDs []int32) *CorporationTracker {
+	tracker := &CorporationTracker{
+		scheduler: scheduler,
+		allowed:   map[int32]bool{},
+	}
+
+	for _, corporationID := range allowedCorporationIDs {
+		tracker.allowed[corporationID] = true
+	}
+
+	return tracker
+}
+
+// Run periodically discovers corporations until the context is cancelled
+func (t *CorporationTracker) Run(ctx context.Context) {
+	for {
+		t.discover(ctx)
+
+		if !sleepContext(ctx, DiscoveryInterval) {
+			return
+		}
+	}
+}
+
+func (t *CorporationTracker) discover(ctx context.Context) {
+	tokens, err := cache.GetCorporationAccessTokens()
+	if err != nil {
+		log.Errorf("Could not discover corporations: %v", err)
+		return
+	}
+
+	current := map[int32]bool{}
+
+	for _, token := range tokens {
+		if len(t.allowed) > 0 && !t.allowed[token.CorporationID] {
+			continue
+		}
+
+		current[token.CorporationID] = true
+	}
+
+	tracked := map[int32]bool{}
+
+	for _, corporationID := range t.scheduler.Corporations() {
+		tracked[corporationID] = true
+
+		if !current[corporationID] {
+			log.Infof("Corporation %d has no director access token anymore, stopping its fetchers", corporationID)
+
+			t.scheduler.Remove(corporationID)
+		}
+	}
+
+	for corporationID := range current {
+		if tracked[corporationID] {
+			continue
+		}
+
+		log.Infof("Discovered corporation %d, starting its fetchers", corporationID)
+
+		for _, service := range NewCorporationServices(corporationID) {
+			t.scheduler.Add(service)
+		}
+
+		if t.RepairJournal {
+			go func(corporationID int32) {
+				if err := RepairJournal(ctx, corporationID); err != nil {
+					log.Errorf("Could not repair journal of corporation %d: %v", corporationID, err)
+				}
+			}(corporationID)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/oxisto/titan/cache"
@@ -38,9 +39,29 @@ type DataFetcher interface {
 	LogFields() logrus.Fields
 }
 
+// FetchService periodically runs a single fetcher for a corporation. It is run by the Scheduler, which
+// shares the ESI error budget with all services. Fetchers of public data, which do not need an access token,
+// are run with a corporation ID of 0.
 type FetchService struct {
 	corporationID int32
 	fetcher       DataFetcher
+	cancel        context.CancelFunc
+
+	mutex  sync.RWMutex
+	status FetchStatus
+}
+
+// FetchStatus describes the state of a fetch service
+type FetchStatus struct {
+	DataType      string        `json:"dataType"`
+	CorporationID int32         `json:"corporationID"`
+	Fields        logrus.Fields `json:"fields"`
+	LastRun       *time.Time    `json:"lastRun"`
+	NextRun       *time.Time    `json:"nextRun"`
+	LastError     string        `json:"lastError"`
+	Failures      int           `json:"failures"`
+	ETag          string        `json:"etag"`
+	NotModified   bool          `json:"notModified"`
 }
 
 type FetchContext struct {
@@ -99,12 +120,28 @@ func NewFetchService(corporationID int32, fetcher DataFetcher) *FetchService {
 	return &FetchService{
 		fetcher:       fetcher,
 		corporationID: corporationID,
+		status: FetchStatus{
+			DataType:      fetcher.DataType(),
+			CorporationID: corporationID,
+			Fields:        fetcher.LogFields(),
+		},
 	}
 }
 
-func (service FetchService) StartLoop() {
+// Status returns a copy of the current status of the service
+func (service *FetchService) Status() FetchStatus {
+	service.mutex.RLock()
+	defer service.mutex.RUnlock()
+
+	return service.status
+}
+
+// run fetches data until the context is cancelled. Before each fetch, it waits until the ESI error budget
+// allows further requests. The ESI client checks the budget before every single request of a fetch as well
+// and updates it with every response. Failures are retried with a capped exponential backoff.
+func (service *FetchService) run(parent context.Context, budget *cache.ErrorBudget) {
 	var (
-		backoffTime = time.Minute
+		backoffTime = MinBackoffTime
 		etag        CachedETag
 		accessToken model.AccessToken
 		ctx         FetchContext
@@ -113,6 +150,7 @@ func (service FetchService) StartLoop() {
 
 	// create a new context
 	ctx = FetchContext{
+		Context:       parent,
 		corporationID: service.corporationID,
 		log: log.WithFields(logrus.Fields{
 			"data":          service.fetcher.DataType(),
@@ -120,87 +158,144 @@ func (service FetchService) StartLoop() {
 		}).WithFields(service.fetcher.LogFields()),
 	}
 
+	// check, if we have an ETag in our cache
+	if err = cache.ReadCachedObject(fmt.Sprintf("etag:%s", cacheKey(ctx, service.fetcher)), &etag); err != nil {
+		// just ignore cache errors, because they do not influence our fetching
+		ctx.log.Debugf("Could not read ETag: %v", err)
+	} else {
+		ctx.lastETag = etag.ETag
+	}
+
 	for {
-		if ctx.lastETag == "" {
-			// check, if we have an ETag in our cache
-			_ = cache.ReadCachedObject(fmt.Sprintf("etag:%s", cacheKey(ctx, service.fetcher)), &etag)
-			if err != nil {
-				// just warn and ignore cache errors, because they do not influence our fetching
-				log.Warnf("Could not read ETag: %v", err)
-			} else {
-				ctx.lastETag = etag.ETag
-			}
+		if !budget.Wait(ctx) {
+			return
 		}
 
 		ctx.log.Printf("Fetching %s...", service.fetcher.DataType())
 
-		// find access token for corporation
-		err = cache.GetAccessTokenForCorporation(ctx.corporationID, &accessToken)
-		if err != nil {
-			ctx.log.Errorf("Could not find access token for %d.", ctx.corporationID, backoffTime.Minutes())
+		// find access token for corporation, public data does not need one
+		if ctx.corporationID != 0 {
+			if err = cache.GetAccessTokenForCorporation(ctx.corporationID, &accessToken); err != nil {
+				ctx.log.Errorf("Could not find access token for %d: %v", ctx.corporationID, err)
 
-			// this error could occur, if no access tokens are ready yet. let's wait for a little bit
-			sleepWithPrint(ctx.log, backoffTime)
+				// this error could occur, if no access tokens are ready yet. let's wait for a little bit
+				if !service.wait(ctx, fmt.Errorf("no access token: %w", err), nil, &backoffTime) {
+					return
+				}
 
-			// increase the backoff time
-			backoffTime = backoffTime * 2
+				continue
+			}
 
-			continue
+			// update the context with the access token
+			ctx.accessToken = &accessToken
 		}
 
-		// update the context with the access token
-		ctx.accessToken = &accessToken
-
-		// let the fetcher do its work. it will return the time we need to sleep
+		// let the fetcher do its work
 		httpResponse, err := service.fetcher.Fetch(ctx)
+
 		if err != nil {
 			ctx.log.Printf("An error occured while fetching %s: %v", service.fetcher.DataType(), err)
 
-			// just to be sure, we wait for the maximum time to not bother ESI too much
-			sleepWithPrint(ctx.log, service.fetcher.MaxCacheTime())
+			if !service.wait(ctx, err, httpResponse, &backoffTime) {
+				return
+			}
+
 			continue
 		}
 
+		// successful fetches reset the backoff
+		backoffTime = MinBackoffTime
+
+		var duration time.Duration
+
 		// try to parse the expires header from the http response
-		expireTime, err := time.Parse(time.RFC1123, httpResponse.Header.Get("expires"))
-		if err != nil {
+		if expireTime, err := time.Parse(time.RFC1123, httpResponse.Header.Get("expires")); err != nil {
 			ctx.log.Printf("An error occured while parsing the expires header: %v", err)
 
 			// just to be sure, we wait for the maximum time to not bother ESI too much
-			sleepWithPrint(ctx.log, service.fetcher.MaxCacheTime())
-			continue
+			duration = service.fetcher.MaxCacheTime()
+		} else {
+			duration = time.Until(expireTime)
 		}
 
-		etag = CachedETag{
-			ETag: httpResponse.Header.Get("etag"),
-			Key:  cacheKey(ctx, service.fetcher),
+		// sometimes, the duration is negative, this can occur because of clock offset between the server and our client.
+		// in this case we need to assume the maximum time
+		if duration < 0 {
+			duration = service.fetcher.MaxCacheTime()
 		}
 
-		// update the ETag directly
-		ctx.lastETag = etag.ETag
+		if tag := httpResponse.Header.Get("etag"); tag != "" {
+			etag = CachedETag{
+				ETag: tag,
+				Key:  cacheKey(ctx, service.fetcher),
+			}
 
-		// cache the ETag
-		err = cache.WriteCachedObject(&etag)
-		if err != nil {
-			// just warn and ignore cache errors, because they do not influence our fetching
-			log.Warnf("Could not cache ETag: %v", err)
-		}
+			// update the ETag directly
+			ctx.lastETag = etag.ETag
 
-		var duration = time.Until(expireTime)
+			// cache the ETag
+			if err = cache.WriteCachedObject(&etag); err != nil {
+				// just warn and ignore cache errors, because they do not influence our fetching
+				ctx.log.Warnf("Could not cache ETag: %v", err)
+			}
+		}
 
-		// sometimes, the duration is negative, this can occur because of clock offset between the server and our client.
-		// in this case we need to assume the maximum time
-		if duration < 0 {
-			duration = service.fetcher.MaxCacheTime()
+		if !service.sleep(ctx, nil, httpResponse, duration) {
+			return
 		}
+	}
+}
+
+// wait records a failed run and waits for the backoff time, which is doubled for the next failure
+func (service *FetchService) wait(ctx FetchContext, err error, httpResponse *http.Response, backoffTime *time.Duration) bool {
+	duration := *backoffTime
+
+	*backoffTime *= 2
+	if *backoffTime > MaxBackoffTime {
+		*backoffTime = MaxBackoffTime
+	}
+
+	return service.sleep(ctx, err, httpResponse, duration)
+}
 
-		sleepWithPrint(ctx.log, duration)
+// sleep records the result of a run and waits for the specified duration. It returns false, if the context was
+// cancelled in the meantime.
+func (service *FetchService) sleep(ctx FetchContext, err error, httpResponse *http.Response, duration time.Duration) bool {
+	now := time.Now()
+	next := now.Add(duration)
+
+	service.mutex.Lock()
+	service.status.LastRun = &now
+	service.status.NextRun = &next
+	service.status.ETag = ctx.lastETag
+
+	if err != nil {
+		service.status.LastError = err.Error()
+		service.status.Failures++
+	} else {
+		service.status.LastError = ""
+		service.status.Failures = 0
 	}
+
+	service.status.NotModified = httpResponse != nil && httpResponse.StatusCode == http.StatusNotModified
+	service.mutex.Unlock()
+
+	ctx.log.Printf("Waiting for %.2f minutes until next fetch", duration.Minutes())
+
+	return sleepContext(ctx, duration)
 }
 
-func sleepWithPrint(log *logrus.Entry, duration time.Duration) {
-	log.Printf("Waiting for %.2f minutes until next fetch", duration.Minutes())
-	time.Sleep(duration)
+// sleepContext waits for the specified duration. It returns false, if the context was cancelled in the meantime.
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
 
 func cacheKey(ctx FetchContext, fetcher DataFetcher) string {
@@ -2,6 +2,7 @@ package datafetch
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -28,32 +29,46 @@ func NewIndustryJobsFetcher() DataFetcher {
 }
 
 func (i *industryJobsFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
-	var options esi.GetCorporationsCorporationIdIndustryJobsOpts
-	options.IncludeCompleted = optional.NewBool(true)
+	pages, err := fetchPages(ctx, i, false, func(page int32, etag string) (interface{}, *http.Response, error) {
+		var options esi.GetCorporationsCorporationIdIndustryJobsOpts
+		options.IncludeCompleted = optional.NewBool(true)
+		options.Page = optional.NewInt32(page)
 
-	if ctx.lastETag != "" {
-		options.IfNoneMatch = optional.NewString(ctx.lastETag)
-	}
+		if etag != "" {
+			options.IfNoneMatch = optional.NewString(etag)
+		}
 
-	response, httpResponse, err := cache.ESI.IndustryApi.GetCorporationsCorporationIdIndustryJobs(
-		context.WithValue(context.Background(),
-			goesi.ContextAccessToken,
-			ctx.accessToken.Token),
-		ctx.corporationID,
-		&options)
+		return cache.ESI.IndustryApi.GetCorporationsCorporationIdIndustryJobs(
+			context.WithValue(ctx,
+				goesi.ContextAccessToken,
+				ctx.accessToken.Token),
+			ctx.corporationID,
+			&options)
+	})
 	if err != nil {
-		return httpResponse, err
+		return pages.First(), err
 	}
 
+	httpResponse := pages.First()
+
 	limitFields := logrus.Fields{
 		"esi-err-remain": httpResponse.Header.Get("x-esi-error-limit-remain"),
 		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
+		"pages":          len(pages.responses),
+	}
+
+	if !pages.Modified() {
+		ctx.log.WithFields(limitFields).Info("Industry jobs have not changed")
+
+		return httpResponse, nil
 	}
 
-	if httpResponse.StatusCode != 304 {
-		ctx.log.WithFields(limitFields).Infof("Retrieved %d industry jobs", len(response))
+	jobs := []model.IndustryJob{}
+
+	// loop through all jobs of all modified pages
+	for _, result := range pages.results {
+		response, _ := result.([]esi.GetCorporationsCorporationIdIndustryJobs200Ok)
 
-		// loop through all jobs
 		for _, t := range response {
 			job := model.IndustryJob{
 				ActivityID:           t.ActivityId,
@@ -75,25 +90,33 @@ func (i *industryJobsFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
 				StartDate:            t.StartDate,
 				Status:               t.Status,
 				SuccesfulRuns:        t.SuccessfulRuns,
+				CorporationID:        ctx.corporationID,
 			}
 
+			// t is re-used by the loop, so we need to copy the dates before we keep pointers to them
 			if !t.CompletedDate.IsZero() {
-				job.CompletedDate = &t.CompletedDate
+				completedDate := t.CompletedDate
+				job.CompletedDate = &completedDate
 			}
 
 			if !t.PauseDate.IsZero() {
-				job.PauseDate = &t.PauseDate
+				pauseDate := t.PauseDate
+				job.PauseDate = &pauseDate
 			}
 
 			ctx.log.Debugf("Discovered industry job %d (%d, %d)", job.JobID, job.ActivityID, job.BlueprintTypeID)
 
-			if err := db.UpdateIndustryJob(&job); err != nil {
-				ctx.log.Errorf("Could not update industry job ID %d: %v", job.JobID, err)
-			}
+			jobs = append(jobs, job)
 		}
-	} else {
-		ctx.log.WithFields(limitFields).Info("Industry jobs have not changed")
 	}
 
+	ctx.log.WithFields(limitFields).Infof("Retrieved %d industry jobs", len(jobs))
+
+	if err = db.UpdateIndustryJobs(jobs); err != nil {
+		return httpResponse, fmt.Errorf("could not update industry jobs: %w", err)
+	}
+
+	pages.saveETags(ctx)
+
 	return httpResponse, nil
 }
@@ -2,6 +2,7 @@ package datafetch
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -32,31 +33,66 @@ func NewJournalFetcher(division int32) DataFetcher {
 }
 
 func (f *journalFechter) Fetch(ctx FetchContext) (*http.Response, error) {
+	pages, err := fetchPages(ctx, f, false, func(page int32, etag string) (interface{}, *http.Response, error) {
+		return f.fetchPage(ctx, page, etag)
+	})
+	if err != nil {
+		return pages.First(), err
+	}
+
+	httpResponse := pages.First()
+
+	limitFields := logrus.Fields{
+		"esi-err-remain": httpResponse.Header.Get("x-esi-error-limit-remain"),
+		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
+		"pages":          len(pages.responses),
+	}
+
+	if !pages.Modified() {
+		ctx.log.WithFields(limitFields).Info("Journal has not changed")
+
+		return httpResponse, nil
+	}
+
+	entries := f.newJournalEntries(ctx, pages)
+
+	ctx.log.WithFields(limitFields).Infof("Retrieved %d journal entries", len(entries))
+
+	// all pages are stored at once, otherwise a failed insert would leave gaps in the journal that
+	// are never filled, because the pages are reported as not modified afterwards
+	if err = db.InsertJournalEntries(entries); err != nil {
+		return httpResponse, fmt.Errorf("could not insert journal entries: %w", err)
+	}
+
+	pages.saveETags(ctx)
+
+	return httpResponse, nil
+}
+
+func (f *journalFechter) fetchPage(ctx FetchContext, page int32, etag string) (interface{}, *http.Response, error) {
 	var options esi.GetCorporationsCorporationIdWalletsDivisionJournalOpts
-	if ctx.lastETag != "" {
-		options.IfNoneMatch = optional.NewString(ctx.lastETag)
+	options.Page = optional.NewInt32(page)
+
+	if etag != "" {
+		options.IfNoneMatch = optional.NewString(etag)
 	}
 
-	response, httpResponse, err := cache.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionJournal(
-		context.WithValue(context.Background(),
+	return cache.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionJournal(
+		context.WithValue(ctx,
 			goesi.ContextAccessToken,
 			ctx.accessToken.Token),
 		ctx.corporationID,
 		f.division,
 		&options)
-	if err != nil {
-		return httpResponse, err
-	}
+}
 
-	limitFields := logrus.Fields{
-		"esi-err-remain": httpResponse.Header.Get("x-esi-error-limit-remain"),
-		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
-	}
+// newJournalEntries converts the journal entries of all modified pages
+func (f *journalFechter) newJournalEntries(ctx FetchContext, pages *pageSet) []model.JournalEntry {
+	entries := []model.JournalEntry{}
 
-	if httpResponse.StatusCode != 304 {
-		ctx.log.WithFields(limitFields).Infof("Retrieved %d journal entries", len(response))
+	for _, result := range pages.results {
+		response, _ := result.([]esi.GetCorporationsCorporationIdWalletsDivisionJournal200Ok)
 
-		// loop through all journal entries
 		for _, journal := range response {
 			entry := model.JournalEntry{
 				Amount:        journal.Amount,
@@ -68,18 +104,74 @@ func (f *journalFechter) Fetch(ctx FetchContext) (*http.Response, error) {
 				RefType:       journal.RefType,
 				SecondPartyID: journal.SecondPartyId,
 				CorporationID: ctx.corporationID,
-				Division:      1,
+				Division:      f.division,
+				ContextID:     journal.ContextId,
+				ContextIDType: journal.ContextIdType,
+				Reason:        journal.Reason,
+				Tax:           journal.Tax,
+				TaxReceiverID: journal.TaxReceiverId,
 			}
 
 			ctx.log.Debugf("Discovered new journal entry %d (%s, %.2f ISK))", entry.ID, entry.Description, entry.Amount)
 
-			if err := db.InsertJournalEntry(entry); err != nil {
-				ctx.log.Errorf("Could not insert journal entry with ID %d: %v", entry.ID, err.Error())
-			}
+			entries = append(entries, entry)
 		}
-	} else {
-		ctx.log.WithFields(limitFields).Info("Journal has not changed")
 	}
 
-	return httpResponse, nil
+	return entries
+}
+
+// RepairJournal fetches the journal of all wallet divisions of a corporation once more, ignoring any ETags, and
+// replaces the stored rows of the fetched ref IDs. Older versions stored every entry in the first division, kept
+// only one row per ref ID and did not store the context of an entry. ESI only returns the journal of the last 30 days, so older entries are corrected using
+// the division of the market transaction they belong to.
+func RepairJournal(parent context.Context, corporationID int32) error {
+	var accessToken model.AccessToken
+
+	if err := cache.GetAccessTokenForCorporation(corporationID, &accessToken); err != nil {
+		return fmt.Errorf("could not find access token: %w", err)
+	}
+
+	entries := []model.JournalEntry{}
+
+	// the rows of a ref ID are replaced in all divisions at once, so all divisions need to be fetched first
+	for division := int32(1); division <= WalletDivisions; division++ {
+		f := NewJournalFetcher(division).(*journalFechter)
+
+		ctx := FetchContext{
+			Context:       parent,
+			corporationID: corporationID,
+			accessToken:   &accessToken,
+			log: log.WithFields(logrus.Fields{
+				"data":          "journal-repair",
+				"corporationID": corporationID,
+			}).WithFields(f.LogFields()),
+		}
+
+		pages, err := fetchPages(ctx, f, false, func(page int32, _ string) (interface{}, *http.Response, error) {
+			return f.fetchPage(ctx, page, "")
+		})
+		if err != nil {
+			return fmt.Errorf("could not fetch journal of division %d: %w", division, err)
+		}
+
+		divisionEntries := f.newJournalEntries(ctx, pages)
+
+		ctx.log.Infof("Fetched %d journal entries", len(divisionEntries))
+
+		entries = append(entries, divisionEntries...)
+	}
+
+	if err := db.ReplaceJournalEntries(corporationID, entries); err != nil {
+		return fmt.Errorf("could not replace journal entries: %w", err)
+	}
+
+	repaired, err := db.RepairJournalDivisions(corporationID)
+	if err != nil {
+		return fmt.Errorf("could not repair journal divisions: %w", err)
+	}
+
+	log.WithField("corporationID", corporationID).Infof("Corrected the division of %d older journal entries", repaired)
+
+	return nil
 }
@@ -0,0 +1,154 @@
+package datafetch
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// marketHistoryConcurrency limits the number of market history requests that are issued to ESI at the same time
+const marketHistoryConcurrency = 10
+
+type marketHistoryFetcher struct {
+	metadata
+}
+
+// NewMarketHistoryFetcher creates a fetcher for the daily market history of all producible types in every region
+// that is in use, i.e. the regions of the known trade hubs and of the trade hubs selected in the settings.
+// ESI only updates the market history once a day, so there is no need to fetch it more often.
+func NewMarketHistoryFetcher() DataFetcher {
+	return &marketHistoryFetcher{
+		metadata: metadata{
+			dataType:     "market-history",
+			maxCacheTime: time.Hour * 24,
+		},
+	}
+}
+
+// regionIDs returns the distinct regions, for which the market history is needed
+func (f *marketHistoryFetcher) regionIDs() ([]int32, error) {
+	settingsRegionIDs, err := db.GetSettingsRegionIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[int32]bool{}
+	regionIDs := []int32{}
+
+	for _, hub := range model.TradeHubs {
+		settingsRegionIDs = append(settingsRegionIDs, hub.RegionID)
+	}
+
+	for _, regionID := range settingsRegionIDs {
+		if regionID == 0 || seen[regionID] {
+			continue
+		}
+
+		seen[regionID] = true
+		regionIDs = append(regionIDs, regionID)
+	}
+
+	return regionIDs, nil
+}
+
+func (f *marketHistoryFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
+	var (
+		mutex        sync.Mutex
+		wg           sync.WaitGroup
+		semaphore    = make(chan struct{}, marketHistoryConcurrency)
+		lastResponse *http.Response
+		lastErr      error
+		failed       int
+	)
+
+	typeIDs, err := db.GetProductTypeIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	regionIDs, err := f.regionIDs()
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve regions: %w", err)
+	}
+
+	for _, regionID := range regionIDs {
+		for _, typeID := range typeIDs {
+			// stop early, if we are shutting down
+			if ctx.Err() != nil {
+				break
+			}
+
+			wg.Add(1)
+			semaphore <- struct{}{}
+
+			go func(regionID int32, typeID int32) {
+				defer func() {
+					<-semaphore
+					wg.Done()
+				}()
+
+				httpResponse, err := f.fetchType(ctx, regionID, typeID)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if err != nil {
+					ctx.log.Debugf("Could not fetch market history of type %d in region %d: %v", typeID, regionID, err)
+					failed++
+					lastErr = err
+					return
+				}
+
+				lastResponse = httpResponse
+			}(regionID, typeID)
+		}
+	}
+
+	wg.Wait()
+
+	// all requests share the same expiry, so any successful response is good enough for caching
+	if lastResponse == nil {
+		return nil, fmt.Errorf("could not fetch any market history: %w", lastErr)
+	}
+
+	ctx.log.Infof("Retrieved market history of %d types in %d regions, %d failed", len(typeIDs), len(regionIDs), failed)
+
+	return lastResponse, nil
+}
+
+func (f *marketHistoryFetcher) fetchType(ctx FetchContext, regionID int32, typeID int32) (*http.Response, error) {
+	response, httpResponse, err := cache.ESI.MarketApi.GetMarketsRegionIdHistory(ctx, regionID, typeID, nil)
+	if err != nil {
+		return httpResponse, err
+	}
+
+	history := []model.MarketHistoryEntry{}
+	for _, h := range response {
+		entry := model.MarketHistoryEntry{
+			RegionID:   regionID,
+			TypeID:     typeID,
+			Average:    h.Average,
+			Highest:    h.Highest,
+			Lowest:     h.Lowest,
+			OrderCount: h.OrderCount,
+			Volume:     h.Volume,
+		}
+
+		if entry.Date, err = time.Parse("2006-01-02", h.Date); err != nil {
+			return httpResponse, err
+		}
+
+		history = append(history, entry)
+	}
+
+	if err = db.UpdateMarketHistory(history); err != nil {
+		return httpResponse, fmt.Errorf("could not update market history: %w", err)
+	}
+
+	return httpResponse, nil
+}
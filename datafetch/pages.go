@@ -0,0 +1,127 @@
+package datafetch
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/oxisto/titan/cache"
+)
+
+// pageFunc fetches a single page of a paginated resource. If etag is not empty, it should be sent as
+// If-None-Match header. The decoded result of the page is handed back to the fetcher in page order.
+type pageFunc func(page int32, etag string) (interface{}, *http.Response, error)
+
+// pageSet holds the results of all pages of a paginated resource
+type pageSet struct {
+	key       string
+	results   []interface{}
+	responses []*http.Response
+}
+
+// fetchPages fetches all pages of a paginated resource. Every page has its own ETag, so that pages
+// which did not change are answered with 304 Not Modified and their result is nil. The ETag of the
+// first page is the one the fetch service keeps track of, the others are cached by the page set once
+// the fetcher calls saveETags.
+//
+// Data that is replaced as a whole needs all pages, as soon as one of them changed. If complete is
+// set, pages that were not modified are requested once more without ETag in this case.
+func fetchPages(ctx FetchContext, fetcher DataFetcher, complete bool, fetch pageFunc) (*pageSet, error) {
+	var mutex sync.Mutex
+
+	set := &pageSet{
+		key: cacheKey(ctx, fetcher),
+	}
+
+	responses, err := cache.FetchPages(ctx, func(page int32) (*http.Response, error) {
+		result, response, err := fetch(page, set.etag(ctx, page))
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// the number of pages is only known after the first page
+		for int(page) > len(set.results) {
+			set.results = append(set.results, nil)
+		}
+
+		set.results[page-1] = result
+
+		return response, err
+	})
+
+	set.responses = responses
+
+	if err != nil {
+		return set, err
+	}
+
+	if !complete || !set.Modified() {
+		return set, nil
+	}
+
+	for i, response := range set.responses {
+		if response.StatusCode != http.StatusNotModified {
+			continue
+		}
+
+		// these are usually only a few pages, so we can just fetch them one by one
+		if set.results[i], set.responses[i], err = fetch(int32(i+1), ""); err != nil {
+			return set, fmt.Errorf("could not fetch page %d: %w", i+1, err)
+		}
+	}
+
+	return set, nil
+}
+
+// etag returns the last known ETag of a page
+func (set *pageSet) etag(ctx FetchContext, page int32) string {
+	if page == 1 {
+		return ctx.lastETag
+	}
+
+	var etag CachedETag
+
+	if err := cache.ReadCachedObject(fmt.Sprintf("etag:%s:page:%d", set.key, page), &etag); err != nil {
+		return ""
+	}
+
+	return etag.ETag
+}
+
+// saveETags caches the ETags of all pages except the first one, which is cached by the fetch service.
+// It should only be called once the results of all pages have been stored, otherwise pages could be
+// reported as not modified, although their results were never stored.
+func (set *pageSet) saveETags(ctx FetchContext) {
+	for i, response := range set.responses[1:] {
+		tag := response.Header.Get("etag")
+		if tag == "" {
+			continue
+		}
+
+		etag := CachedETag{
+			ETag: tag,
+			Key:  fmt.Sprintf("%s:page:%d", set.key, i+2),
+		}
+
+		if err := cache.WriteCachedObject(&etag); err != nil {
+			// just warn and ignore cache errors, because they do not influence our fetching
+			ctx.log.Warnf("Could not cache ETag: %v", err)
+		}
+	}
+}
+
+// First returns the response of the first page, which contains the caching headers for the fetch service
+func (set *pageSet) First() *http.Response {
+	return set.responses[0]
+}
+
+// Modified returns true, if at least one page was modified
+func (set *pageSet) Modified() bool {
+	for _, response := range set.responses {
+		if response.StatusCode != http.StatusNotModified {
+			return true
+		}
+	}
+
+	return false
+}
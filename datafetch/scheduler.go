@@ -0,0 +1,144 @@
+package datafetch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oxisto/titan/cache"
+)
+
+const (
+	// MinBackoffTime is the time we wait after the first failed fetch
+	MinBackoffTime = time.Minute
+
+	// MaxBackoffTime caps the time we wait after repeated failures
+	MaxBackoffTime = time.Minute * 30
+)
+
+// Scheduler owns all fetch services. It starts and stops them and makes sure, that they share the global ESI
+// error budget of the ESI client.
+type Scheduler struct {
+	mutex    sync.RWMutex
+	services []*FetchService
+	budget   *cache.ErrorBudget
+	ctx      context.Context
+	wg       sync.WaitGroup
+}
+
+// SchedulerStatus describes the state of the scheduler and all its fetch services
+type SchedulerStatus struct {
+	ErrorLimitRemain int           `json:"errorLimitRemain"`
+	ErrorLimitReset  *time.Time    `json:"errorLimitReset"`
+	Paused           bool          `json:"paused"`
+	Fetchers         []FetchStatus `json:"fetchers"`
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		budget: cache.Budget,
+	}
+}
+
+// Add adds a fetch service to the scheduler. If the scheduler is already started, the service is started immediately.
+func (s *Scheduler) Add(service *FetchService) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.services = append(s.services, service)
+
+	if s.ctx != nil {
+		s.start(service)
+	}
+}
+
+// Start starts all fetch services. They run until the context is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.ctx = ctx
+
+	for _, service := range s.services {
+		s.start(service)
+	}
+}
+
+func (s *Scheduler) start(service *FetchService) {
+	var ctx context.Context
+
+	// every service gets its own context, so that it can be stopped on its own
+	ctx, service.cancel = context.WithCancel(s.ctx)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		service.run(ctx, s.budget)
+	}()
+}
+
+// Remove stops and removes all fetch services of a corporation
+func (s *Scheduler) Remove(corporationID int32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	services := []*FetchService{}
+
+	for _, service := range s.services {
+		if service.corporationID != corporationID {
+			services = append(services, service)
+			continue
+		}
+
+		if service.cancel != nil {
+			service.cancel()
+		}
+	}
+
+	s.services = services
+}
+
+// Corporations returns the IDs of all corporations that have at least one fetch service
+func (s *Scheduler) Corporations() []int32 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	corporationIDs := []int32{}
+	seen := map[int32]bool{}
+
+	for _, service := range s.services {
+		if service.corporationID == 0 || seen[service.corporationID] {
+			continue
+		}
+
+		seen[service.corporationID] = true
+		corporationIDs = append(corporationIDs, service.corporationID)
+	}
+
+	return corporationIDs
+}
+
+// Wait blocks until all fetch services have stopped
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Status returns the status of the scheduler and all its fetch services
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	status := SchedulerStatus{
+		Fetchers: []FetchStatus{},
+	}
+
+	status.ErrorLimitRemain, status.ErrorLimitReset, status.Paused = s.budget.Status()
+
+	for _, service := range s.services {
+		status.Fetchers = append(status.Fetchers, service.Status())
+	}
+
+	return status
+}
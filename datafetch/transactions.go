@@ -2,6 +2,7 @@ package datafetch
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -31,19 +32,21 @@ func NewTransactionFetcher(division int32) DataFetcher {
 	}
 }
 
+// Fetch retrieves the wallet transactions of a division. Transactions are not paginated using X-Pages,
+// instead ESI returns the most recent transactions and older ones need to be requested using from_id.
+// We walk backwards until we reach a transaction we already know or ESI has no older transactions.
 func (f *transactionFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
+	latest, err := db.GetLatestTransaction(ctx.corporationID, f.division)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve latest transaction: %w", err)
+	}
+
 	var options esi.GetCorporationsCorporationIdWalletsDivisionTransactionsOpts
 	if ctx.lastETag != "" {
 		options.IfNoneMatch = optional.NewString(ctx.lastETag)
 	}
 
-	response, httpResponse, err := cache.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionTransactions(
-		context.WithValue(context.Background(),
-			goesi.ContextAccessToken,
-			ctx.accessToken.Token),
-		ctx.corporationID,
-		f.division,
-		&options)
+	response, httpResponse, err := f.fetchTransactions(ctx, &options)
 	if err != nil {
 		return httpResponse, err
 	}
@@ -53,8 +56,16 @@ func (f *transactionFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
 		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
 	}
 
-	if httpResponse.StatusCode != 304 {
-		ctx.log.WithFields(limitFields).Infof("Retrieved %d transactions", len(response))
+	if httpResponse.StatusCode == 304 {
+		ctx.log.WithFields(limitFields).Info("Transactions have not changed")
+
+		return httpResponse, nil
+	}
+
+	transactions := []model.Transaction{}
+
+	for len(response) > 0 {
+		oldest := response[0].TransactionId
 
 		// loop through all transactions
 		for _, t := range response {
@@ -74,13 +85,41 @@ func (f *transactionFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
 
 			ctx.log.Debugf("Discovered new transaction %d (%d, %d x %.2f ISK))", transaction.TransactionID, transaction.TypeID, transaction.Quantity, transaction.UnitPrice)
 
-			if err := db.InsertTransaction(&transaction); err != nil {
-				ctx.log.Errorf("Could not insert transaction with ID %d: %v", transaction.TransactionID, err.Error())
+			transactions = append(transactions, transaction)
+
+			if t.TransactionId < oldest {
+				oldest = t.TransactionId
 			}
 		}
-	} else {
-		ctx.log.WithFields(limitFields).Info("Transactions have not changed")
+
+		// stop, once we have reached transactions that we already know
+		if latest != nil && oldest <= latest.TransactionID {
+			break
+		}
+
+		var olderOptions esi.GetCorporationsCorporationIdWalletsDivisionTransactionsOpts
+		olderOptions.FromId = optional.NewInt64(oldest - 1)
+
+		if response, _, err = f.fetchTransactions(ctx, &olderOptions); err != nil {
+			return httpResponse, fmt.Errorf("could not fetch transactions before %d: %w", oldest, err)
+		}
+	}
+
+	ctx.log.WithFields(limitFields).Infof("Retrieved %d transactions", len(transactions))
+
+	if err = db.InsertTransactions(transactions); err != nil {
+		return httpResponse, fmt.Errorf("could not insert transactions: %w", err)
 	}
 
 	return httpResponse, nil
 }
+
+func (f *transactionFetcher) fetchTransactions(ctx FetchContext, options *esi.GetCorporationsCorporationIdWalletsDivisionTransactionsOpts) ([]esi.GetCorporationsCorporationIdWalletsDivisionTransactions200Ok, *http.Response, error) {
+	return cache.ESI.WalletApi.GetCorporationsCorporationIdWalletsDivisionTransactions(
+		context.WithValue(ctx,
+			goesi.ContextAccessToken,
+			ctx.accessToken.Token),
+		ctx.corporationID,
+		f.division,
+		options)
+}
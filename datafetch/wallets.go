@@ -0,0 +1,80 @@
+package datafetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/antihax/goesi"
+	"github.com/antihax/goesi/esi"
+	"github.com/antihax/goesi/optional"
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+	"github.com/sirupsen/logrus"
+)
+
+type walletsFetcher struct {
+	metadata
+}
+
+// NewWalletsFetcher creates a fetcher that stores a snapshot of the balances of all wallet divisions, so that
+// the balance history is known even if no journal entries are created for a while
+func NewWalletsFetcher() DataFetcher {
+	return &walletsFetcher{
+		metadata: metadata{
+			dataType:     "wallets",
+			maxCacheTime: time.Hour,
+		},
+	}
+}
+
+func (f *walletsFetcher) Fetch(ctx FetchContext) (*http.Response, error) {
+	var options esi.GetCorporationsCorporationIdWalletsOpts
+
+	if ctx.lastETag != "" {
+		options.IfNoneMatch = optional.NewString(ctx.lastETag)
+	}
+
+	response, httpResponse, err := cache.ESI.WalletApi.GetCorporationsCorporationIdWallets(
+		context.WithValue(ctx,
+			goesi.ContextAccessToken,
+			ctx.accessToken.Token),
+		ctx.corporationID,
+		&options)
+	if err != nil {
+		return httpResponse, err
+	}
+
+	limitFields := logrus.Fields{
+		"esi-err-remain": httpResponse.Header.Get("x-esi-error-limit-remain"),
+		"esi-err-reset":  httpResponse.Header.Get("x-esi-error-limit-reset"),
+	}
+
+	if httpResponse.StatusCode == http.StatusNotModified {
+		ctx.log.WithFields(limitFields).Info("Wallets have not changed")
+
+		return httpResponse, nil
+	}
+
+	balances := []model.WalletBalance{}
+	now := time.Now()
+
+	for _, wallet := range response {
+		balances = append(balances, model.WalletBalance{
+			CorporationID: ctx.corporationID,
+			Division:      wallet.Division,
+			Date:          now,
+			Balance:       wallet.Balance,
+		})
+	}
+
+	ctx.log.WithFields(limitFields).Infof("Retrieved balances of %d wallets", len(balances))
+
+	if err = db.InsertWalletBalances(balances); err != nil {
+		return httpResponse, fmt.Errorf("could not insert wallet balances: %w", err)
+	}
+
+	return httpResponse, nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/oxisto/titan/model"
+)
+
+// MinStructureID is the lowest ID of player-owned structures. Lower location IDs belong to stations or solar systems.
+const MinStructureID = 1000000000000
+
+// AssetFilter restricts the assets returned by GetCorporationAssets. Zero values are not used for filtering.
+type AssetFilter struct {
+	// LocationID matches either the direct location of an asset, such as a container, or the station,
+	// structure or solar system it is stored in
+	LocationID int64
+	TypeID     int32
+	Division   int32
+}
+
+func GetCorporationAssets(corporationID int32, filter AssetFilter) ([]*model.AssetWithTypeNames, error) {
+	assets := []*model.AssetWithTypeNames{}
+
+	err := pdb.Select(&assets, `SELECT
+		assets.*,
+		"invTypes"."typeName",
+		COALESCE("staStations"."stationName", structures.name, "mapSolarSystems"."solarSystemName") AS "rootLocationName"
+	FROM
+		assets
+		LEFT JOIN evesde."invTypes" USING ("typeID")
+		LEFT JOIN evesde."staStations" ON ("staStations"."stationID" = assets."rootLocationID")
+		LEFT JOIN structures ON (structures."structureID" = assets."rootLocationID")
+		LEFT JOIN evesde."mapSolarSystems" ON ("mapSolarSystems"."solarSystemID" = assets."rootLocationID")
+	WHERE
+		assets."corporationID" = $1
+		AND ($2::bigint = 0 OR assets."locationID" = $2 OR assets."rootLocationID" = $2)
+		AND ($3::integer = 0 OR assets."typeID" = $3)
+		AND ($4::integer = 0 OR assets.division = $4)
+	ORDER BY "rootLocationName", assets.division, "typeName"`,
+		corporationID,
+		filter.LocationID,
+		filter.TypeID,
+		filter.Division)
+
+	return assets, err
+}
+
+// ReplaceCorporationAssets replaces all assets of a corporation at once, so that assets which were used up,
+// sold or moved away since the last fetch are removed as well
+func ReplaceCorporationAssets(corporationID int32, assets []model.Asset) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM assets WHERE "corporationID" = $1`, corporationID); err != nil {
+		return err
+	}
+
+	for _, a := range assets {
+		_, err = tx.Exec(`INSERT INTO
+		assets
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT("itemID") DO UPDATE
+		SET
+			"corporationID" = $2,
+			"typeID" = $3,
+			quantity = $4,
+			"isSingleton" = $5,
+			"locationID" = $6,
+			"locationFlag" = $7,
+			"locationType" = $8,
+			"rootLocationID" = $9,
+			division = $10`,
+			a.ItemID,
+			a.CorporationID,
+			a.TypeID,
+			a.Quantity,
+			a.IsSingleton,
+			a.LocationID,
+			a.LocationFlag,
+			a.LocationType,
+			a.RootLocationID,
+			a.Division)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetUnknownStructureIDs returns the IDs of all structures, in which a corporation stores assets, but whose
+// names we do not know yet
+func GetUnknownStructureIDs(corporationID int32) ([]int64, error) {
+	structureIDs := []int64{}
+
+	err := pdb.Select(&structureIDs, `SELECT DISTINCT
+		assets."rootLocationID"
+	FROM
+		assets
+		LEFT JOIN structures ON (structures."structureID" = assets."rootLocationID")
+	WHERE
+		assets."corporationID" = $1
+		AND assets."rootLocationID" >= $2
+		AND structures."structureID" IS NULL`, corporationID, MinStructureID)
+
+	return structureIDs, err
+}
+
+// GetLocationSolarSystems returns the solar systems of the specified stations and structures. Structures are only
+// known, if the corporation stores assets in them.
+func GetLocationSolarSystems(locationIDs []int64) (map[int64]int32, error) {
+	systems := map[int64]int32{}
+
+	if len(locationIDs) == 0 {
+		return systems, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT
+		"stationID" AS "locationID",
+		"solarSystemID"
+	FROM
+		evesde."staStations"
+	WHERE
+		"stationID" IN (?)
+	UNION ALL
+	SELECT
+		"structureID" AS "locationID",
+		"solarSystemID"
+	FROM
+		structures
+	WHERE
+		"structureID" IN (?)`, locationIDs, locationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		LocationID    int64 `db:"locationID"`
+		SolarSystemID int32 `db:"solarSystemID"`
+	}{}
+
+	if err = pdb.Select(&rows, pdb.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		systems[row.LocationID] = row.SolarSystemID
+	}
+
+	return systems, nil
+}
+
+func UpdateStructure(structure model.Structure) error {
+	_, err := pdb.Exec(`INSERT INTO
+	structures
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT("structureID") DO UPDATE
+	SET
+		name = $2,
+		"solarSystemID" = $3,
+		"typeID" = $4`,
+		structure.StructureID,
+		structure.Name,
+		structure.SolarSystemID,
+		structure.TypeID)
+
+	return err
+}
+
+// GetAssetQuantities returns the quantities of the specified types, that a corporation stores at a location. The
+// location can be a station, a structure or a container. If division is 0, all hangar divisions are considered.
+// Assembled items, such as ships, are not counted, because they cannot be used as materials.
+func GetAssetQuantities(corporationID int32, locationID int64, division int32, typeIDs []int32) (quantities map[int32]int, err error) {
+	quantities = map[int32]int{}
+
+	if len(typeIDs) == 0 {
+		return quantities, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT
+		"typeID",
+		SUM(quantity) AS quantity
+	FROM
+		assets
+	WHERE
+		"corporationID" = ?
+		AND ("locationID" = ? OR "rootLocationID" = ?)
+		AND (?::integer = 0 OR division = ?)
+		AND NOT "isSingleton"
+		AND "typeID" IN (?)
+	GROUP BY "typeID"`, corporationID, locationID, locationID, division, division, typeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		TypeID   int32 `db:"typeID"`
+		Quantity int   `db:"quantity"`
+	}{}
+
+	if err = pdb.Select(&rows, pdb.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		quantities[row.TypeID] = row.Quantity
+	}
+
+	return quantities, nil
+}
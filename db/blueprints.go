@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"github.com/oxisto/titan/model"
+)
+
+func GetCorporationBlueprints(corporationID int32) ([]*model.CorporationBlueprintWithTypeNames, error) {
+	blueprints := []*model.CorporationBlueprintWithTypeNames{}
+
+	err := pdb.Select(&blueprints, `SELECT
+		"corporationBlueprints".*,
+		"invTypes"."typeName"
+	FROM
+		"corporationBlueprints"
+		LEFT JOIN evesde."invTypes" USING ("typeID")
+	WHERE
+		"corporationID" = $1
+	ORDER BY "typeName", "materialEfficiency" DESC, "timeEfficiency" DESC`, corporationID)
+
+	return blueprints, err
+}
+
+// GetBestCorporationBlueprint returns the owned blueprint of the specified type with the best material and
+// time efficiency. Originals are preferred over copies and copies with more runs are preferred. It returns
+// nil, if the corporation does not own such a blueprint.
+func GetBestCorporationBlueprint(corporationID int32, blueprintTypeID int32) (*model.CorporationBlueprint, error) {
+	blueprints := []model.CorporationBlueprint{}
+
+	err := pdb.Select(&blueprints, `SELECT
+		*
+	FROM
+		"corporationBlueprints"
+	WHERE
+		"corporationID" = $1
+		AND "typeID" = $2
+	ORDER BY
+		"materialEfficiency" DESC,
+		"timeEfficiency" DESC,
+		"quantity" = -2,
+		"runs" DESC
+	LIMIT 1`, corporationID, blueprintTypeID)
+	if err != nil || len(blueprints) == 0 {
+		return nil, err
+	}
+
+	return &blueprints[0], nil
+}
+
+// CountCorporationBlueprints returns the number of owned blueprints of the specified type. Stacks of originals
+// are counted by their size.
+func CountCorporationBlueprints(corporationID int32, blueprintTypeID int32) (count int, err error) {
+	err = pdb.Get(&count, `SELECT
+		COALESCE(SUM(CASE WHEN "quantity" > 0 THEN "quantity" ELSE 1 END), 0)
+	FROM
+		"corporationBlueprints"
+	WHERE
+		"corporationID" = $1
+		AND "typeID" = $2`, corporationID, blueprintTypeID)
+
+	return count, err
+}
+
+// ReplaceCorporationBlueprints replaces all blueprints of a corporation at once, so that blueprints which
+// were consumed or sold since the last fetch are removed as well
+func ReplaceCorporationBlueprints(corporationID int32, blueprints []model.CorporationBlueprint) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM "corporationBlueprints" WHERE "corporationID" = $1`, corporationID); err != nil {
+		return err
+	}
+
+	for _, b := range blueprints {
+		_, err = tx.Exec(`INSERT INTO
+		"corporationBlueprints"
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT("itemID") DO UPDATE
+		SET
+			"corporationID" = $2,
+			"typeID" = $3,
+			"locationID" = $4,
+			"locationFlag" = $5,
+			"quantity" = $6,
+			"materialEfficiency" = $7,
+			"timeEfficiency" = $8,
+			"runs" = $9`,
+			b.ItemID,
+			b.CorporationID,
+			b.TypeID,
+			b.LocationID,
+			b.LocationFlag,
+			b.Quantity,
+			b.MaterialEfficiency,
+			b.TimeEfficiency,
+			b.Runs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"database/sql"
+
+	"github.com/oxisto/titan/model"
+)
+
+func GetFacilities(corporationID int32) ([]model.Facility, error) {
+	facilities := []model.Facility{}
+
+	err := pdb.Select(&facilities, `SELECT
+		*
+	FROM
+		facilities
+	WHERE
+		"corporationID" = $1
+	ORDER BY name`, corporationID)
+
+	return facilities, err
+}
+
+func GetFacility(corporationID int32, facilityID int32) (*model.Facility, error) {
+	var facility model.Facility
+
+	err := pdb.Get(&facility, `SELECT
+		*
+	FROM
+		facilities
+	WHERE
+		"corporationID" = $1
+		AND "facilityID" = $2`, corporationID, facilityID)
+
+	return &facility, err
+}
+
+func InsertFacility(facility *model.Facility) error {
+	return pdb.Get(&facility.FacilityID, `INSERT INTO facilities
+		("corporationID", name, "structureType", "solarSystemID", security, tax, rigs)
+	VALUES($1, $2, $3, $4, $5, $6, $7)
+	RETURNING "facilityID"`,
+		facility.CorporationID,
+		facility.Name,
+		facility.StructureType,
+		facility.SolarSystemID,
+		facility.Security,
+		facility.Tax,
+		facility.Rigs)
+}
+
+// UpdateFacility updates a facility of a corporation. It returns sql.ErrNoRows, if the corporation has no such
+// facility.
+func UpdateFacility(facility *model.Facility) error {
+	result, err := pdb.Exec(`UPDATE facilities
+	SET
+		name = $3,
+		"structureType" = $4,
+		"solarSystemID" = $5,
+		security = $6,
+		tax = $7,
+		rigs = $8
+	WHERE
+		"corporationID" = $1
+		AND "facilityID" = $2`,
+		facility.CorporationID,
+		facility.FacilityID,
+		facility.Name,
+		facility.StructureType,
+		facility.SolarSystemID,
+		facility.Security,
+		facility.Tax,
+		facility.Rigs)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func DeleteFacility(corporationID int32, facilityID int32) error {
+	_, err := pdb.Exec(`DELETE FROM facilities WHERE "corporationID" = $1 AND "facilityID" = $2`, corporationID, facilityID)
+
+	return err
+}
@@ -1,6 +1,11 @@
 package db
 
-import "github.com/oxisto/titan/model"
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oxisto/titan/model"
+)
 
 func GetIndustryJobs(corporationID int32) ([]*model.IndustryJobWithTypeNames, error) {
 	jobs := []*model.IndustryJobWithTypeNames{}
@@ -13,15 +18,39 @@ func GetIndustryJobs(corporationID int32) ([]*model.IndustryJobWithTypeNames, er
 		"industryJobs"
 		LEFT JOIN evesde."invTypes" AS "blueprintTypes" ON ("blueprintTypes"."typeID" = "industryJobs"."blueprintTypeID")
 		LEFT JOIN evesde."invTypes" AS "productTypes" ON ("productTypes"."typeID" = "industryJobs"."productTypeID")
-	`)
+	WHERE
+		"industryJobs"."corporationID" = $1
+	`, corporationID)
 
 	return jobs, err
 }
 
-func UpdateIndustryJob(job *model.IndustryJob) error {
-	_, err := pdb.Exec(`INSERT INTO
+// UpdateIndustryJobs inserts or updates all industry jobs in a single transaction
+func UpdateIndustryJobs(jobs []model.IndustryJob) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range jobs {
+		if err = updateIndustryJob(tx, &jobs[i]); err != nil {
+			return fmt.Errorf("could not update industry job %d: %w", jobs[i].JobID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func updateIndustryJob(tx *sqlx.Tx, job *model.IndustryJob) error {
+	_, err := tx.Exec(`INSERT INTO
 	"industryJobs"
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	ON CONFLICT("jobID") DO UPDATE
 	SET
 		"activityID" = $2,
@@ -43,7 +72,8 @@ func UpdateIndustryJob(job *model.IndustryJob) error {
 		"productTypeID" = $18,
 		"runs" = $19,
 		"succesfulRuns" = $20,
-		"status" = $21`,
+		"status" = $21,
+		"corporationID" = $22`,
 		job.JobID,
 		job.ActivityID,
 		job.CompletedCharacterID,
@@ -64,7 +94,30 @@ func UpdateIndustryJob(job *model.IndustryJob) error {
 		job.ProductTypeID,
 		job.Runs,
 		job.SuccesfulRuns,
-		job.Status)
+		job.Status,
+		job.CorporationID)
 
 	return err
 }
+
+// GetDeliveredIndustryJobs returns all delivered jobs of a corporation for the specified activities, ordered by their start
+func GetDeliveredIndustryJobs(corporationID int32, activityIDs []model.IndustryActivityID) ([]model.IndustryJob, error) {
+	jobs := []model.IndustryJob{}
+
+	query, args, err := sqlx.In(`SELECT
+		*
+	FROM
+		"industryJobs"
+	WHERE
+		"corporationID" = ?
+		AND status = 'delivered'
+		AND "activityID" IN (?)
+	ORDER BY "startDate"`, corporationID, activityIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pdb.Select(&jobs, pdb.Rebind(query), args...)
+
+	return jobs, err
+}
@@ -57,6 +57,7 @@ type ProductTypeResult struct {
 		Total float64 `json:"total"`
 	} `json:"costs"`
 	HasRequiredSkills bool `json:"hasRequiredSkills"`
+	HasBlueprint      bool `json:"hasBlueprint" db:"hasBlueprint"`
 }
 
 type IndustryActivityResult struct {
@@ -78,6 +79,13 @@ type IndustryActivityProbabilityResult struct {
 	Probability   float64 `json:"probability" db:"probability"`
 }
 
+type IndustryActivityProductResult struct {
+	TypeID        int32 `json:"typeID" db:"typeID"`
+	ActivityID    int32 `json:"activityID" db:"activityID"`
+	ProductTypeID int32 `json:"productTypeID" db:"productTypeID"`
+	Quantity      int   `json:"quantity" db:"quantity"`
+}
+
 type BlueprintResult struct {
 	model.Blueprint
 }
@@ -92,6 +100,9 @@ type SearchOptions struct {
 	Offset                int
 	Limit                 int
 	HasRequiredSkillsOnly bool
+	OwnedBlueprintsOnly   bool
+	// CorporationID is the corporation whose profits are used, because they depend on its owned blueprints
+	CorporationID int32
 }
 
 func NewSearchOptions() *SearchOptions {
@@ -105,17 +116,20 @@ func NewSearchOptions() *SearchOptions {
 	return options
 }
 
-func UpdateProfit(m model.Manufacturing) {
-	log.Debugf("Updating profit for %s (%d)...", m.Product.TypeName, m.Product.TypeID)
+// UpdateProfit stores the profit of a product for a corporation. The profit depends on the blueprints the
+// corporation owns, so every corporation has its own profits.
+func UpdateProfit(corporationID int32, m model.Manufacturing) {
+	log.Debugf("Updating profit for %s (%d) of corporation %d...", m.Product.TypeName, m.Product.TypeID, corporationID)
 
-	_, err := pdb.Exec(`INSERT INTO profit ("typeID", "basedOnSellPrice", "basedOnBuyPrice")
-        VALUES ($1, $2, $3) ON CONFLICT ("typeID")
+	_, err := pdb.Exec(`INSERT INTO profit ("corporationID", "typeID", "basedOnSellPrice", "basedOnBuyPrice", "hasBlueprint")
+        VALUES ($1, $2, $3, $4, $5) ON CONFLICT ("corporationID", "typeID")
         DO
         UPDATE
         SET
             "basedOnSellPrice" = excluded. "basedOnSellPrice",
-            "basedOnBuyPrice" = excluded. "basedOnBuyPrice"
-`, m.Product.TypeID, m.Profit.PerDay.BasedOnSellPrice, m.Profit.PerDay.BasedOnBuyPrice)
+            "basedOnBuyPrice" = excluded. "basedOnBuyPrice",
+            "hasBlueprint" = excluded. "hasBlueprint"
+`, corporationID, m.Product.TypeID, m.Profit.PerDay.BasedOnSellPrice, m.Profit.PerDay.BasedOnBuyPrice, m.HasBlueprint)
 
 	if err != nil {
 		log.Printf("Could not update profit: %v", err)
@@ -167,13 +181,16 @@ WHERE
 	return categories, err
 }
 
+// GetActivityMaterials returns the materials of a single job with the specified runs. Like in the game, the
+// material modifier is applied to all runs at once and rounded up, but a job needs at least one unit per run.
 func GetActivityMaterials(activityID model.IndustryActivityID, blueprint model.Blueprint, runs int, materialModifier float64) ([]IndustryActivityMaterialResult, error) {
 	materials := []IndustryActivityMaterialResult{}
 
 	err := pdb.Select(&materials, `SELECT
     "invTypes"."typeID",
     "invTypes"."typeName",
-	CAST(CEIL(quantity * $3 * $4::double precision) AS integer) AS quantity,
+    "invTypes".volume,
+	CAST(GREATEST($3::integer, CEIL(ROUND(CAST(quantity * $3 * $4::double precision AS numeric), 2))) AS integer) AS quantity,
 	"quantity" AS "rawQuantity"
 FROM
     evesde. "industryActivityMaterials"
@@ -215,6 +232,20 @@ WHERE
 	return result, err
 }
 
+func GetActivityProduct(activityID model.IndustryActivityID, blueprintTypeID int32, productTypeID int32) (IndustryActivityProductResult, error) {
+	var result = IndustryActivityProductResult{}
+
+	err := pdb.Get(&result, `SELECT * FROM
+	evesde."industryActivityProducts"
+WHERE
+	"typeID" = $1
+	AND	"activityID" = $2
+	AND "productTypeID" = $3
+`, blueprintTypeID, activityID, productTypeID)
+
+	return result, err
+}
+
 func GetActivitySkills(activityID model.IndustryActivityID, blueprint model.Blueprint) ([]IndustryActivitySkillResult, error) {
 	skills := []IndustryActivitySkillResult{}
 
@@ -251,6 +282,43 @@ WHERE
 	return blueprint
 }
 
+// GetBlueprints returns all blueprints that yield the product with the specified activity. Tech 3 blueprints
+// for example can be reverse engineered from several relics.
+func GetBlueprints(activityID model.IndustryActivityID, productTypeID int32) ([]BlueprintResult, error) {
+	blueprints := []BlueprintResult{}
+
+	err := pdb.Select(&blueprints, `SELECT
+    "industryBlueprints".*
+FROM
+    evesde. "industryActivityProducts"
+    JOIN evesde. "industryBlueprints" USING ("typeID")
+WHERE
+    "activityID" = $1
+    AND "productTypeID" = $2
+ORDER BY
+    "typeID"
+`, activityID, productTypeID)
+
+	return blueprints, err
+}
+
+// GetRelicTypeIDs returns the type IDs of all ancient relics that can be reverse engineered
+func GetRelicTypeIDs() []int32 {
+	types := []int32{}
+
+	pdb.Select(&types, `SELECT DISTINCT
+    "industryActivityProducts"."typeID"
+FROM
+    evesde. "industryActivityProducts"
+    JOIN evesde. "invTypes" USING ("typeID")
+    JOIN evesde. "invGroups" USING ("groupID")
+WHERE
+    "activityID" = 8
+    AND "categoryID" = 34`)
+
+	return types
+}
+
 func GetType(typeID int32) (*model.Type, error) {
 	t := model.Type{}
 
@@ -270,20 +338,22 @@ WHERE
 	return &t, err
 }
 
+// GetProductTypeIDs returns the distinct published types with a market group that can be manufactured or reacted.
 func GetProductTypeIDs() ([]int32, error) {
 	types := []int32{}
 
-	err := pdb.Select(&types, `SELECT
+	err := pdb.Select(&types, `SELECT DISTINCT
     "invTypes"."typeID"
 FROM
     evesde. "industryActivityProducts"
     JOIN evesde. "invTypes" ON ("invTypes"."typeID" = "productTypeID")
     LEFT JOIN evesde. "invMetaTypes" ON ("invMetaTypes"."typeID" = "invTypes"."typeID")
 WHERE
-    "activityID" = 1
+    "activityID" IN (1, 11)
     AND published = TRUE
+    AND "invTypes"."marketGroupID" IS NOT NULL
     AND ("metaGroupID" IS NULL
-        OR "metaGroupID" IN (1, 2))
+        OR "metaGroupID" IN (1, 2, 14))
 `)
 
 	return types, err
@@ -307,6 +377,8 @@ WHERE
 	return types
 }
 
+// GetProductTypes returns the types that can be manufactured or reacted, together with their cached profit for the
+// corporation of the options
 func GetProductTypes(options *SearchOptions) ([]ProductTypeResult, error) {
 	types := []ProductTypeResult{}
 
@@ -319,22 +391,33 @@ func GetProductTypes(options *SearchOptions) ([]ProductTypeResult, error) {
     "invTypes"."typeName",
     "invGroups"."categoryID",
     profit. "basedOnBuyPrice",
-    profit. "basedOnSellPrice"
+    profit. "basedOnSellPrice",
+    COALESCE(profit. "hasBlueprint", FALSE) AS "hasBlueprint"
 FROM
-    evesde. "industryActivityProducts"
-    JOIN evesde. "invTypes" ON ("invTypes"."typeID" = "productTypeID")
+    evesde. "invTypes"
     LEFT JOIN evesde. "invMetaTypes" ON ("invMetaTypes"."typeID" = "invTypes"."typeID")
     LEFT JOIN evesde. "invGroups" USING ("groupID")
-    LEFT JOIN profit ON ("invTypes"."typeID" = profit. "typeID")
+    LEFT JOIN profit ON ("invTypes"."typeID" = profit. "typeID"
+            AND profit. "corporationID" = $4)
 WHERE
-    "activityID" = 1
+    -- a type can have several blueprints or formulas, but must only be listed once
+    EXISTS (
+        SELECT
+            1
+        FROM
+            evesde. "industryActivityProducts"
+        WHERE
+            "productTypeID" = "invTypes"."typeID"
+            AND "activityID" IN (1, 11))
     AND "invTypes".published = TRUE
     AND ("metaGroupID" IS NULL
-        OR "metaGroupID" IN (1, 2))
+        OR "metaGroupID" IN (1, 2, 14))
     AND "typeName" ILIKE $2
+    AND ($3 = FALSE
+        OR profit. "hasBlueprint" IS TRUE)
 ORDER BY
     "`+options.SortByField+`" DESC NULLS LAST, "typeName"
-LIMIT $1`, options.Limit, "%"+options.NameFilter+"%")
+LIMIT $1`, options.Limit, "%"+options.NameFilter+"%", options.OwnedBlueprintsOnly, options.CorporationID)
 
 	return types, err
 }
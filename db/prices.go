@@ -0,0 +1,151 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"time"
+
+	"github.com/oxisto/titan/model"
+)
+
+// InsertPrices stores a snapshot of the specified prices, so that their history can be analyzed later on
+func InsertPrices(prices map[int32]model.Price, date time.Time) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, p := range prices {
+		_, err = tx.Exec(`INSERT INTO
+		prices
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT DO NOTHING`,
+			p.TypeID,
+			p.LocationID,
+			date,
+			p.Buy.Percentile,
+			p.Buy.Volume,
+			p.Buy.OrderCount,
+			p.Sell.Percentile,
+			p.Sell.Volume,
+			p.Sell.OrderCount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetPriceHistory returns the price history of a type at a location between from and to. All snapshots of a
+// day are averaged into a single entry.
+func GetPriceHistory(locationID int64, typeID int32, from time.Time, to time.Time) ([]model.PriceHistoryEntry, error) {
+	history := []model.PriceHistoryEntry{}
+
+	err := pdb.Select(&history, `SELECT
+		date_trunc('day', date) AS date,
+		AVG("buyPercentile") AS "buyPercentile",
+		CAST(AVG("buyVolume") AS bigint) AS "buyVolume",
+		CAST(AVG("buyOrderCount") AS integer) AS "buyOrderCount",
+		AVG("sellPercentile") AS "sellPercentile",
+		CAST(AVG("sellVolume") AS bigint) AS "sellVolume",
+		CAST(AVG("sellOrderCount") AS integer) AS "sellOrderCount"
+	FROM
+		prices
+	WHERE
+		"locationID" = $1
+		AND "typeID" = $2
+		AND date BETWEEN $3 AND $4
+	GROUP BY
+		date_trunc('day', date)
+	ORDER BY
+		date`, locationID, typeID, from, to)
+
+	return history, err
+}
+
+// UpdateMarketHistory stores the market history of a type. Existing days are updated, since ESI reports the
+// most recent day before it is complete.
+func UpdateMarketHistory(history []model.MarketHistoryEntry) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, h := range history {
+		_, err = tx.Exec(`INSERT INTO
+		"marketHistory"
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT("regionID", "typeID", date) DO UPDATE
+		SET
+			average = $4,
+			highest = $5,
+			lowest = $6,
+			"orderCount" = $7,
+			volume = $8`,
+			h.RegionID,
+			h.TypeID,
+			h.Date,
+			h.Average,
+			h.Highest,
+			h.Lowest,
+			h.OrderCount,
+			h.Volume)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetMarketDemand returns the average units of a type traded per day in a region. Days without any trades
+// count as zero. It returns nil, if there is no market history of the type in the region.
+func GetMarketDemand(regionID int32, typeID int32) (*model.MarketDemand, error) {
+	var demand struct {
+		model.MarketDemand
+		Days int `db:"days"`
+	}
+
+	err := pdb.Get(&demand, `SELECT
+		COALESCE(SUM(volume) FILTER (WHERE date > CURRENT_DATE - 30), 0) / 30.0 AS "averageDailyVolume30",
+		COALESCE(SUM(volume), 0) / 90.0 AS "averageDailyVolume90",
+		COUNT(*) AS days
+	FROM
+		"marketHistory"
+	WHERE
+		"regionID" = $1
+		AND "typeID" = $2
+		AND date > CURRENT_DATE - 90`, regionID, typeID)
+	if err != nil || demand.Days == 0 {
+		return nil, err
+	}
+
+	return &demand.MarketDemand, nil
+}
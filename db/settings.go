@@ -0,0 +1,67 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"github.com/oxisto/titan/model"
+)
+
+func GetSettings(characterID int32) (*model.Settings, error) {
+	var settings model.Settings
+
+	err := pdb.Get(&settings, `SELECT
+		*
+	FROM
+		settings
+	WHERE
+		"characterID" = $1`, characterID)
+
+	return &settings, err
+}
+
+func UpdateSettings(settings *model.Settings) error {
+	_, err := pdb.Exec(`INSERT INTO
+	settings
+	VALUES($1, $2, $3, $4, $5, $6)
+	ON CONFLICT("characterID") DO UPDATE
+	SET
+		"hubName" = $2,
+		"hubRegionID" = $3,
+		"hubStationID" = $4,
+		"materialStrategy" = $5,
+		"productStrategy" = $6`,
+		settings.CharacterID,
+		settings.TradeHub.Name,
+		settings.TradeHub.RegionID,
+		settings.TradeHub.StationID,
+		settings.MaterialStrategy,
+		settings.ProductStrategy)
+
+	return err
+}
+
+// GetSettingsRegionIDs returns the regions of all trade hubs that are selected in the settings of any character.
+func GetSettingsRegionIDs() ([]int32, error) {
+	regionIDs := []int32{}
+
+	err := pdb.Select(&regionIDs, `SELECT DISTINCT
+		"hubRegionID"
+	FROM
+		settings`)
+
+	return regionIDs, err
+}
@@ -19,7 +19,10 @@ package db
 import (
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/oxisto/titan/model"
 )
 
@@ -31,8 +34,42 @@ func GetJournalEntryIDs() ([]int64, error) {
 	return journalIDs, err
 }
 
-func InsertJournalEntry(entry model.JournalEntry) error {
-	_, err := pdb.Exec(`INSERT INTO journal VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT DO NOTHING`,
+// InsertJournalEntries inserts all journal entries in a single transaction. The same ref ID can appear in
+// several divisions, for example on both sides of a transfer between divisions, so entries are identified by
+// corporation, division and ref ID. Journal entries never change, but entries that already exist get their
+// context updated, because older versions did not store it.
+func InsertJournalEntries(entries []model.JournalEntry) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, entry := range entries {
+		if err = insertJournalEntry(tx, entry); err != nil {
+			return fmt.Errorf("could not insert journal entry %d: %w", entry.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertJournalEntry(tx *sqlx.Tx, entry model.JournalEntry) error {
+	_, err := tx.Exec(`INSERT INTO journal
+		(id, amount, balance, date, description, "firstPartyID", "refType", "secondPartyID", "corporationID", division,
+		"contextID", "contextIDType", reason, tax, "taxReceiverID")
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	ON CONFLICT ("corporationID", division, id) DO UPDATE SET
+		"contextID" = EXCLUDED."contextID",
+		"contextIDType" = EXCLUDED."contextIDType",
+		reason = EXCLUDED.reason,
+		tax = EXCLUDED.tax,
+		"taxReceiverID" = EXCLUDED."taxReceiverID"`,
 		entry.ID,
 		entry.Amount,
 		entry.Balance,
@@ -43,11 +80,122 @@ func InsertJournalEntry(entry model.JournalEntry) error {
 		entry.SecondPartyID,
 		entry.CorporationID,
 		entry.Division,
+		entry.ContextID,
+		entry.ContextIDType,
+		entry.Reason,
+		entry.Tax,
+		entry.TaxReceiverID,
 	)
 
 	return err
 }
 
+// ReplaceJournalEntries replaces all stored rows of the ref IDs of the entries with the entries. Older versions
+// stored every entry in the first division and only kept the first entry of a ref ID, so the stored rows of a
+// ref ID can have both the wrong division and the amount of another division.
+func ReplaceJournalEntries(corporationID int32, entries []model.JournalEntry) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, entry := range entries {
+		if _, err = tx.Exec(`DELETE FROM journal WHERE "corporationID" = $1 AND id = $2`, corporationID, entry.ID); err != nil {
+			return fmt.Errorf("could not delete journal entry %d: %w", entry.ID, err)
+		}
+	}
+
+	for _, entry := range entries {
+		if err = insertJournalEntry(tx, entry); err != nil {
+			return fmt.Errorf("could not insert journal entry %d: %w", entry.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RepairJournalDivisions corrects the division of journal entries that belong to a market transaction, using the
+// division of the transaction. This covers entries that are too old to be fetched from ESI again. It returns the
+// number of corrected entries.
+func RepairJournalDivisions(corporationID int32) (int64, error) {
+	result, err := pdb.Exec(`UPDATE
+		journal
+	SET
+		division = transactions.division
+	FROM
+		transactions
+	WHERE
+		journal."corporationID" = $1
+		AND transactions."corporationID" = journal."corporationID"
+		AND transactions."journalRefID" = journal.id
+		AND transactions.division <> journal.division
+		AND NOT EXISTS (
+			SELECT
+				1
+			FROM
+				journal AS existing
+			WHERE
+				existing."corporationID" = journal."corporationID"
+				AND existing.division = transactions.division
+				AND existing.id = journal.id)`, corporationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetJournalEntries returns the journal entries of a corporation between from and to, newest first. Entries that
+// belong to a market transaction are linked to it by their context ID. Entries stored before the context was
+// known are linked by the journal reference of the transaction instead. If division is 0, all divisions are
+// included.
+func GetJournalEntries(corporationID int32, division int32, from time.Time, to time.Time) ([]*model.JournalEntryWithTransaction, error) {
+	entries := []*model.JournalEntryWithTransaction{}
+
+	err := pdb.Select(&entries, `SELECT
+		journal.id,
+		journal.amount,
+		journal.balance,
+		journal.date,
+		journal.description,
+		journal."firstPartyID",
+		journal."refType",
+		journal."secondPartyID",
+		journal."corporationID",
+		journal.division,
+		COALESCE(journal."contextID", 0) AS "contextID",
+		COALESCE(journal."contextIDType", '') AS "contextIDType",
+		COALESCE(journal.reason, '') AS reason,
+		COALESCE(journal.tax, 0) AS tax,
+		COALESCE(journal."taxReceiverID", 0) AS "taxReceiverID",
+		transactions."transactionID",
+		transactions."typeID",
+		"invTypes"."typeName",
+		transactions.quantity,
+		transactions."unitPrice"
+	FROM
+		journal
+		LEFT JOIN transactions ON (transactions."corporationID" = journal."corporationID"
+			AND transactions.division = journal.division
+			AND ((journal."contextIDType" = $5 AND transactions."transactionID" = journal."contextID")
+				OR (journal."contextIDType" IS NULL AND transactions."journalRefID" = journal.id)))
+		LEFT JOIN evesde."invTypes" ON ("invTypes"."typeID" = transactions."typeID")
+	WHERE
+		journal."corporationID" = $1
+		AND ($2::integer = 0 OR journal.division = $2)
+		AND journal.date >= $3
+		AND journal.date < $4
+	ORDER BY journal.date DESC, journal.id DESC`, corporationID, division, from, to, model.ContextIDTypeMarketTransaction)
+
+	return entries, err
+}
+
 func GetLatestTransaction(corporationID int32, division int32) (*model.Transaction, error) {
 	var transaction model.Transaction
 
@@ -69,8 +217,35 @@ func GetLatestTransaction(corporationID int32, division int32) (*model.Transacti
 	return &transaction, nil
 }
 
-func InsertTransaction(transaction *model.Transaction) error {
-	_, err := pdb.Exec(`INSERT INTO transactions VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT DO NOTHING`,
+// InsertTransactions inserts all transactions in a single transaction. Transactions that already exist
+// are left untouched.
+func InsertTransactions(transactions []model.Transaction) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for i := range transactions {
+		if err = insertTransaction(tx, &transactions[i]); err != nil {
+			return fmt.Errorf("could not insert transaction %d: %w", transactions[i].TransactionID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertTransaction inserts a transaction, unless it already exists. Buyer and seller share the transaction ID,
+// so a trade between two tracked corporations, or between two divisions of the same corporation, is stored once
+// for every side.
+func insertTransaction(tx *sqlx.Tx, transaction *model.Transaction) error {
+	_, err := tx.Exec(`INSERT INTO transactions VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT ("corporationID", division, "transactionID") DO NOTHING`,
 		transaction.TransactionID,
 		transaction.ClientID,
 		transaction.Date,
@@ -85,3 +260,167 @@ func InsertTransaction(transaction *model.Transaction) error {
 
 	return err
 }
+
+// GetAverageBuyPrices returns the average price per unit, that a corporation paid for the specified types according
+// to its wallet transactions. Types that were never bought are missing in the result.
+func GetAverageBuyPrices(corporationID int32, typeIDs []int32) (prices map[int32]float64, err error) {
+	prices = map[int32]float64{}
+
+	if len(typeIDs) == 0 {
+		return prices, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT
+		"typeID",
+		SUM("unitPrice" * quantity) / SUM(quantity) AS price
+	FROM
+		transactions
+	WHERE
+		"corporationID" = ?
+		AND "isBuy"
+		AND "typeID" IN (?)
+	GROUP BY "typeID"`, corporationID, typeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []struct {
+		TypeID int32   `db:"typeID"`
+		Price  float64 `db:"price"`
+	}{}
+
+	if err = pdb.Select(&rows, pdb.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		prices[row.TypeID] = row.Price
+	}
+
+	return prices, nil
+}
+
+// GetTransactions returns all transactions of a corporation in all divisions, ordered by their date
+func GetTransactions(corporationID int32) ([]model.Transaction, error) {
+	transactions := []model.Transaction{}
+
+	err := pdb.Select(&transactions, `SELECT
+		*
+	FROM
+		transactions
+	WHERE
+		"corporationID" = $1
+	ORDER BY date, "transactionID"`, corporationID)
+
+	return transactions, err
+}
+
+// JournalSumResult is the sum of all journal entries of a ref type in a wallet division during a period
+type JournalSumResult struct {
+	Start    time.Time `db:"start"`
+	Division int32     `db:"division"`
+	RefType  string    `db:"refType"`
+	Income   float64   `db:"income"`
+	Expenses float64   `db:"expenses"`
+	Entries  int       `db:"entries"`
+}
+
+// GetJournalSums sums up the journal entries of a corporation between from and to per period, division and ref
+// type. Positive and negative amounts are summed up separately as income and expenses. If division is 0, all
+// divisions are included.
+func GetJournalSums(corporationID int32, period model.ReportPeriod, division int32, from time.Time, to time.Time) ([]JournalSumResult, error) {
+	sums := []JournalSumResult{}
+
+	err := pdb.Select(&sums, `SELECT
+		date_trunc($2::text, date) AS start,
+		division,
+		"refType",
+		COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0) AS income,
+		COALESCE(SUM(amount) FILTER (WHERE amount < 0), 0) AS expenses,
+		COUNT(*) AS entries
+	FROM
+		journal
+	WHERE
+		"corporationID" = $1
+		AND ($3::integer = 0 OR division = $3)
+		AND date >= $4
+		AND date < $5
+	GROUP BY start, division, "refType"
+	ORDER BY start, division, "refType"`, corporationID, string(period), division, from, to)
+
+	return sums, err
+}
+
+// InsertWalletBalances stores a snapshot of wallet balances
+func InsertWalletBalances(balances []model.WalletBalance) (err error) {
+	tx, err := pdb.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, balance := range balances {
+		if _, err = tx.Exec(`INSERT INTO "walletBalances" VALUES($1, $2, $3, $4) ON CONFLICT DO NOTHING`,
+			balance.CorporationID,
+			balance.Division,
+			balance.Date,
+			balance.Balance,
+		); err != nil {
+			return fmt.Errorf("could not insert balance of division %d: %w", balance.Division, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBalanceHistory returns the closing balance of every day between from and to, on which the balance of a
+// wallet division is known. The balance is known from the journal, because every entry contains the balance
+// after it, and from the snapshots of the wallet balances. If division is 0, all divisions are included.
+func GetBalanceHistory(corporationID int32, division int32, from time.Time, to time.Time) ([]model.WalletBalance, error) {
+	balances := []model.WalletBalance{}
+
+	err := pdb.Select(&balances, `SELECT DISTINCT ON (division, day)
+		"corporationID",
+		division,
+		day AS date,
+		balance
+	FROM (
+		SELECT "corporationID", division, date, date_trunc('day', date) AS day, balance, id FROM journal
+		UNION ALL
+		SELECT "corporationID", division, date, date_trunc('day', date) AS day, balance, 0 AS id FROM "walletBalances"
+	) AS balances
+	WHERE
+		"corporationID" = $1
+		AND ($2::integer = 0 OR division = $2)
+		AND date >= $3
+		AND date < $4
+	ORDER BY division, day, date DESC, id DESC`, corporationID, division, from, to)
+
+	return balances, err
+}
+
+// GetLatestBalances returns the latest known balance of every wallet division of a corporation
+func GetLatestBalances(corporationID int32) ([]model.WalletBalance, error) {
+	balances := []model.WalletBalance{}
+
+	err := pdb.Select(&balances, `SELECT DISTINCT ON (division)
+		"corporationID",
+		division,
+		date,
+		balance
+	FROM (
+		SELECT "corporationID", division, date, balance, id FROM journal
+		UNION ALL
+		SELECT "corporationID", division, date, balance, 0 AS id FROM "walletBalances"
+	) AS balances
+	WHERE
+		"corporationID" = $1
+	ORDER BY division, date DESC, id DESC`, corporationID)
+
+	return balances, err
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// MaxBuildTreeDepth limits how deep materials are expanded in a build tree
+const MaxBuildTreeDepth = 10
+
+// buildActivities are the activities which can produce a material, in the order they are looked up
+var buildActivities = []model.IndustryActivityID{ActivityManufacturing, ActivityReaction}
+
+// NewBuildTree recursively expands all materials of a product that can be built themselves, either by
+// manufacturing or by reactions. Each node contains the costs to buy and the costs to build the required
+// quantity. Unless overridden by options.BuildDecisions, a node is built if that is cheaper than buying it.
+func NewBuildTree(builder SkillHolder, productTypeID int32, runs int, options *Options) (tree *model.BuildTree, err error) {
+	if options == nil {
+		options = NewOptions()
+	}
+
+	if runs < 1 {
+		runs = 1
+	}
+
+	tree = new(model.BuildTree)
+
+	if tree.Root, err = expandBuildTreeNode(builder, productTypeID, 0, runs, options, 0); err != nil {
+		return nil, err
+	}
+
+	if !tree.Root.Buildable {
+		return nil, errors.New("item cannot be manufactured")
+	}
+
+	// fetch all prices at once
+	typeIDs := []int32{}
+	collectBuildTreeTypeIDs(tree.Root, &typeIDs)
+
+	var prices map[int32]model.Price
+	if prices, err = cache.GetPrices(options.Hub, typeIDs); err != nil {
+		return nil, err
+	}
+
+	tree.CriticalPathTime = calculateBuildTreeNode(tree.Root, prices, options, true)
+	tree.TotalCost = tree.Root.Cost
+	tree.CostPerItem = tree.TotalCost / float64(tree.Root.Runs*tree.Root.UnitsPerRun)
+
+	return tree, nil
+}
+
+// expandBuildTreeNode creates the node for quantity units of the specified type. If the type can be built, the
+// blueprint and all its materials are expanded as well. Instead of a quantity, runs can be specified, which is
+// used for the root node.
+func expandBuildTreeNode(builder SkillHolder, typeID int32, quantity int, runs int, options *Options, depth int) (node *model.BuildTreeNode, err error) {
+	var t *model.Type
+	if t, err = db.GetType(typeID); err != nil {
+		return nil, fmt.Errorf("could not retrieve type: %w", err)
+	}
+
+	node = &model.BuildTreeNode{
+		TypeID:   typeID,
+		TypeName: t.TypeName,
+		Quantity: quantity,
+	}
+
+	var blueprint model.Blueprint
+	for _, activityID := range buildActivities {
+		if blueprint = db.GetBlueprint(activityID, typeID).Blueprint; blueprint.TypeID != 0 {
+			node.ActivityID = activityID
+			break
+		}
+	}
+
+	if blueprint.TypeID == 0 || depth >= MaxBuildTreeDepth {
+		return node, nil
+	}
+
+	node.Buildable = true
+	node.BlueprintTypeID = blueprint.TypeID
+
+	var product db.IndustryActivityProductResult
+	if product, err = db.GetActivityProduct(node.ActivityID, blueprint.TypeID, typeID); err != nil {
+		return nil, err
+	}
+
+	node.UnitsPerRun = product.Quantity
+	if node.UnitsPerRun < 1 {
+		node.UnitsPerRun = 1
+	}
+
+	if runs > 0 {
+		node.Runs = runs
+		node.Quantity = runs * node.UnitsPerRun
+	} else {
+		node.Runs = int(math.Ceil(float64(quantity) / float64(node.UnitsPerRun)))
+	}
+
+	var owned *model.CorporationBlueprint
+	if owned, err = options.ownedBlueprint(blueprint.TypeID); err != nil {
+		return nil, err
+	}
+
+	if node.ActivityID == ActivityManufacturing {
+		if owned != nil {
+			node.ME = int64(owned.MaterialEfficiency)
+			node.TE = int64(owned.TimeEfficiency)
+		} else if t.IsTechII() || t.IsTechIII() {
+			var invention *model.Invention
+			if invention, err = NewInvention(blueprint.TypeID, builder, options); err != nil {
+				return nil, fmt.Errorf("could not invent type: %w", err)
+			}
+
+			node.ME = invention.ME
+			node.TE = invention.TE
+			node.InventionCost = invention.CostsForManufacturing / float64(invention.Runs) * float64(node.Runs)
+		} else {
+			node.ME = options.ME
+			node.TE = options.TE
+		}
+	}
+
+	facility := options.facilityFor(node.ActivityID)
+
+	var facilityModifiers *FacilityModifiers
+	if facilityModifiers, err = NewFacilityModifiers(facility, node.ActivityID, t); err != nil {
+		return nil, err
+	}
+
+	materialModifiers := map[string]float64{"Blueprint Material Efficiency": -float64(node.ME) / 100}
+	for key, mod := range facilityModifiers.Material {
+		materialModifiers[key] = mod
+	}
+
+	timeModifiers := map[string]float64{
+		"Skills":                    skillTimeModifier(builder, node.ActivityID),
+		"Blueprint Time Efficiency": -float64(node.TE) / 100,
+	}
+	for key, mod := range facilityModifiers.Time {
+		timeModifiers[key] = mod
+	}
+
+	var activity db.IndustryActivityResult
+	if activity, err = db.GetIndustryActivity(blueprint.TypeID, node.ActivityID); err != nil {
+		return nil, err
+	}
+
+	node.Time = int(math.Ceil(float64(activity.Time*node.Runs) * CalculateModifier(timeModifiers)))
+
+	var skills []db.IndustryActivitySkillResult
+	if skills, err = db.GetActivitySkills(node.ActivityID, blueprint); err != nil {
+		return nil, err
+	}
+
+	node.RequiredSkills, node.HasRequiredSkills = requiredSkills(builder, skills)
+
+	// job cost
+	var (
+		eiv   float64
+		index *model.SystemCostIndex
+	)
+
+	if eiv, err = EstimatedItemValue(node.ActivityID, blueprint); err != nil {
+		return nil, err
+	}
+
+	if index, err = cache.GetSystemCostIndex(facility.SolarSystemID); err != nil {
+		return nil, fmt.Errorf("could not retrieve system cost index: %w", err)
+	}
+
+	node.JobCost = CalculateJobCost(eiv*float64(node.Runs), index.CostIndex(node.ActivityID), facilityModifiers.Cost, facility.Tax)
+
+	var materials []db.IndustryActivityMaterialResult
+	if materials, err = db.GetActivityMaterials(node.ActivityID, blueprint, node.Runs, CalculateModifier(materialModifiers)); err != nil {
+		return nil, err
+	}
+
+	node.Materials = []*model.BuildTreeNode{}
+	for _, material := range materials {
+		var child *model.BuildTreeNode
+		if child, err = expandBuildTreeNode(builder, material.TypeID, material.Quantity, 0, options, depth+1); err != nil {
+			return nil, err
+		}
+
+		node.Materials = append(node.Materials, child)
+	}
+
+	return node, nil
+}
+
+func collectBuildTreeTypeIDs(node *model.BuildTreeNode, typeIDs *[]int32) {
+	*typeIDs = append(*typeIDs, node.TypeID)
+
+	for _, child := range node.Materials {
+		collectBuildTreeTypeIDs(child, typeIDs)
+	}
+}
+
+// calculateBuildTreeNode calculates the costs of a node bottom-up and decides whether it is built or bought. It
+// returns the time of the critical path to build the node including all built materials: sibling materials are
+// built in parallel, so only the slowest of them counts, while each level waits for the level below it.
+func calculateBuildTreeNode(node *model.BuildTreeNode, prices map[int32]model.Price, options *Options, root bool) (criticalPathTime int) {
+	node.PricePerUnit = prices[node.TypeID].Value(options.MaterialStrategy)
+	node.BuyCost = float64(node.Quantity) * node.PricePerUnit
+
+	if !node.Buildable {
+		node.Build = false
+		node.Cost = node.BuyCost
+		return 0
+	}
+
+	materialsTime := 0
+
+	node.BuildCost = node.JobCost + node.InventionCost
+	for _, child := range node.Materials {
+		childTime := calculateBuildTreeNode(child, prices, options, false)
+
+		node.BuildCost += child.Cost
+
+		if childTime > materialsTime {
+			materialsTime = childTime
+		}
+	}
+
+	if build, ok := options.BuildDecisions[node.TypeID]; ok && !root {
+		node.Build = build
+	} else {
+		// items without market price can only be built
+		node.Build = root || node.BuyCost == 0 || node.BuildCost < node.BuyCost
+	}
+
+	if !node.Build {
+		node.Cost = node.BuyCost
+		return 0
+	}
+
+	node.Cost = node.BuildCost
+
+	return node.Time + materialsTime
+}
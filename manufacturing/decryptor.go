@@ -0,0 +1,72 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/oxisto/titan/model"
+)
+
+const (
+	// NoDecryptor can be used to explicitly invent without a decryptor
+	NoDecryptor = int32(0)
+)
+
+// Decryptors contains all decryptors that can be used during invention. Their modifiers are
+// dogma attributes, which are not part of the SDE tables we import, so we keep them here.
+var Decryptors = map[int32]model.Decryptor{
+	34201: {TypeID: 34201, TypeName: "Accelerant Decryptor", ProbabilityMultiplier: 1.2, MaxRunModifier: 1, MEModifier: 2, TEModifier: 10},
+	34202: {TypeID: 34202, TypeName: "Attainment Decryptor", ProbabilityMultiplier: 1.8, MaxRunModifier: 4, MEModifier: -1, TEModifier: 4},
+	34203: {TypeID: 34203, TypeName: "Augmentation Decryptor", ProbabilityMultiplier: 0.6, MaxRunModifier: 9, MEModifier: -2, TEModifier: 2},
+	34204: {TypeID: 34204, TypeName: "Parity Decryptor", ProbabilityMultiplier: 1.5, MaxRunModifier: 3, MEModifier: 1, TEModifier: -2},
+	34205: {TypeID: 34205, TypeName: "Process Decryptor", ProbabilityMultiplier: 1.1, MaxRunModifier: 0, MEModifier: 3, TEModifier: 6},
+	34206: {TypeID: 34206, TypeName: "Symmetry Decryptor", ProbabilityMultiplier: 1.0, MaxRunModifier: 2, MEModifier: 1, TEModifier: 8},
+	34207: {TypeID: 34207, TypeName: "Optimized Attainment Decryptor", ProbabilityMultiplier: 1.9, MaxRunModifier: 2, MEModifier: 1, TEModifier: -2},
+	34208: {TypeID: 34208, TypeName: "Optimized Augmentation Decryptor", ProbabilityMultiplier: 0.9, MaxRunModifier: 7, MEModifier: 2, TEModifier: 0},
+}
+
+// DecryptorTypeIDs returns the type IDs of all known decryptors, ordered by their ID, so that decryptors with the
+// same profit are always compared in the same order
+func DecryptorTypeIDs() []int32 {
+	typeIDs := []int32{}
+
+	for typeID := range Decryptors {
+		typeIDs = append(typeIDs, typeID)
+	}
+
+	sort.Slice(typeIDs, func(i, j int) bool {
+		return typeIDs[i] < typeIDs[j]
+	})
+
+	return typeIDs
+}
+
+// GetDecryptor looks up a decryptor by its type ID. NoDecryptor returns nil without an error.
+func GetDecryptor(typeID int32) (*model.Decryptor, error) {
+	if typeID == NoDecryptor {
+		return nil, nil
+	}
+
+	decryptor, ok := Decryptors[typeID]
+	if !ok {
+		return nil, fmt.Errorf("type %d is not a decryptor", typeID)
+	}
+
+	return &decryptor, nil
+}
@@ -0,0 +1,340 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"fmt"
+
+	"github.com/oxisto/titan/model"
+)
+
+const (
+	RigGroupEquipment            = "equipment"
+	RigGroupAmmunition           = "ammunition"
+	RigGroupDrones               = "drones"
+	RigGroupBasicSmallShips      = "basicSmallShips"
+	RigGroupBasicMediumShips     = "basicMediumShips"
+	RigGroupBasicLargeShips      = "basicLargeShips"
+	RigGroupAdvancedSmallShips   = "advancedSmallShips"
+	RigGroupAdvancedMediumShips  = "advancedMediumShips"
+	RigGroupAdvancedLargeShips   = "advancedLargeShips"
+	RigGroupCapitalShips         = "capitalShips"
+	RigGroupAdvancedComponents   = "advancedComponents"
+	RigGroupCapitalComponents    = "capitalComponents"
+	RigGroupStructures           = "structures"
+	RigGroupCompositeReactions   = "compositeReactions"
+	RigGroupBiochemicalReactions = "biochemicalReactions"
+	RigGroupHybridReactions      = "hybridReactions"
+)
+
+const (
+	CategoryShip            = 6
+	CategoryModule          = 7
+	CategoryCharge          = 8
+	CategoryDrone           = 18
+	CategoryStarbase        = 23
+	CategoryStructure       = 65
+	CategoryStructureModule = 66
+	CategoryFighter         = 87
+)
+
+// StructureBonus contains the role bonuses of a structure for one particular activity
+type StructureBonus struct {
+	Material float64
+	Time     float64
+	Cost     float64
+}
+
+// FacilityStructures contains all structure types and the bonuses they offer for the activities that can be
+// installed in them. An activity that is missing cannot be installed in that structure type.
+var FacilityStructures = map[string]map[model.IndustryActivityID]StructureBonus{
+	model.StructureNPCStation: {
+		ActivityManufacturing: {},
+		ActivityResearchTE:    {},
+		ActivityResearchME:    {},
+		ActivityCopying:       {},
+		ActivityInvention:     {},
+	},
+	model.StructureRaitaru: {
+		ActivityManufacturing: {Material: 0.01, Time: 0.15, Cost: 0.03},
+		ActivityResearchTE:    {Time: 0.15, Cost: 0.03},
+		ActivityResearchME:    {Time: 0.15, Cost: 0.03},
+		ActivityCopying:       {Time: 0.15, Cost: 0.03},
+		ActivityInvention:     {Time: 0.15, Cost: 0.03},
+	},
+	model.StructureAzbel: {
+		ActivityManufacturing: {Material: 0.01, Time: 0.20, Cost: 0.04},
+		ActivityResearchTE:    {Time: 0.20, Cost: 0.04},
+		ActivityResearchME:    {Time: 0.20, Cost: 0.04},
+		ActivityCopying:       {Time: 0.20, Cost: 0.04},
+		ActivityInvention:     {Time: 0.20, Cost: 0.04},
+	},
+	model.StructureSotiyo: {
+		ActivityManufacturing: {Material: 0.01, Time: 0.30, Cost: 0.05},
+		ActivityResearchTE:    {Time: 0.30, Cost: 0.05},
+		ActivityResearchME:    {Time: 0.30, Cost: 0.05},
+		ActivityCopying:       {Time: 0.30, Cost: 0.05},
+		ActivityInvention:     {Time: 0.30, Cost: 0.05},
+	},
+	model.StructureAthanor: {
+		ActivityReaction: {},
+	},
+	model.StructureTatara: {
+		ActivityReaction: {Time: 0.25},
+	},
+}
+
+// SecurityMultipliers scale the bonuses of structure rigs depending on the security of the solar system
+var SecurityMultipliers = map[string]float64{
+	model.SecurityHigh:     1.0,
+	model.SecurityLow:      1.9,
+	model.SecurityNull:     2.1,
+	model.SecurityWormhole: 2.1,
+}
+
+// ReactionSecurityMultipliers scale the bonuses of refinery rigs. Reactions cannot be run in high-sec at all.
+var ReactionSecurityMultipliers = map[string]float64{
+	model.SecurityLow:      1.0,
+	model.SecurityNull:     1.1,
+	model.SecurityWormhole: 1.1,
+}
+
+// RigBonuses contains the base bonus of rigs per bonus type and tech level
+var RigBonuses = map[string]map[int]float64{
+	model.RigBonusMaterial: {1: 0.02, 2: 0.024},
+	model.RigBonusTime:     {1: 0.20, 2: 0.24},
+}
+
+// RigGroupActivities specifies, which activity the rigs of a rig group apply to
+var RigGroupActivities = map[string]model.IndustryActivityID{
+	RigGroupEquipment:            ActivityManufacturing,
+	RigGroupAmmunition:           ActivityManufacturing,
+	RigGroupDrones:               ActivityManufacturing,
+	RigGroupBasicSmallShips:      ActivityManufacturing,
+	RigGroupBasicMediumShips:     ActivityManufacturing,
+	RigGroupBasicLargeShips:      ActivityManufacturing,
+	RigGroupAdvancedSmallShips:   ActivityManufacturing,
+	RigGroupAdvancedMediumShips:  ActivityManufacturing,
+	RigGroupAdvancedLargeShips:   ActivityManufacturing,
+	RigGroupCapitalShips:         ActivityManufacturing,
+	RigGroupAdvancedComponents:   ActivityManufacturing,
+	RigGroupCapitalComponents:    ActivityManufacturing,
+	RigGroupStructures:           ActivityManufacturing,
+	RigGroupCompositeReactions:   ActivityReaction,
+	RigGroupBiochemicalReactions: ActivityReaction,
+	RigGroupHybridReactions:      ActivityReaction,
+}
+
+var shipSizes = map[int32]string{
+	// small
+	25: "small", 31: "small", 237: "small", 324: "small", 420: "small", 541: "small", 830: "small", 831: "small",
+	834: "small", 893: "small", 1022: "small", 1283: "small", 1305: "small", 1527: "small", 1534: "small",
+	// medium
+	26: "medium", 28: "medium", 358: "medium", 380: "medium", 419: "medium", 463: "medium", 540: "medium", 543: "medium",
+	832: "medium", 833: "medium", 894: "medium", 906: "medium", 963: "medium", 1201: "medium", 1202: "medium", 1972: "medium",
+	// large
+	27: "large", 898: "large", 900: "large", 941: "large",
+	// capital
+	30: "capital", 485: "capital", 513: "capital", 547: "capital", 659: "capital", 883: "capital", 902: "capital",
+	1538: "capital", 4594: "capital",
+}
+
+var basicShipRigGroups = map[string]string{
+	"small":  RigGroupBasicSmallShips,
+	"medium": RigGroupBasicMediumShips,
+	"large":  RigGroupBasicLargeShips,
+}
+
+var advancedShipRigGroups = map[string]string{
+	"small":  RigGroupAdvancedSmallShips,
+	"medium": RigGroupAdvancedMediumShips,
+	"large":  RigGroupAdvancedLargeShips,
+}
+
+var componentRigGroups = map[int32]string{
+	334: RigGroupAdvancedComponents,
+	536: RigGroupStructures,
+	873: RigGroupCapitalComponents,
+	913: RigGroupCapitalComponents,
+	964: RigGroupAdvancedComponents,
+}
+
+var reactionRigGroups = map[int32]string{
+	// intermediate and composite moon materials
+	428:  RigGroupCompositeReactions,
+	429:  RigGroupCompositeReactions,
+	4096: RigGroupCompositeReactions,
+	// booster materials
+	712: RigGroupBiochemicalReactions,
+	// polymers
+	974: RigGroupHybridReactions,
+}
+
+// RigGroup returns the rig group of a product, i.e. which rigs affect its manufacturing. An empty string
+// is returned, if no rig applies to the product.
+func RigGroup(product *model.Type) string {
+	if group, ok := componentRigGroups[product.GroupID]; ok {
+		return group
+	}
+
+	if group, ok := reactionRigGroups[product.GroupID]; ok {
+		return group
+	}
+
+	switch product.CategoryID {
+	case CategoryModule:
+		return RigGroupEquipment
+	case CategoryCharge:
+		return RigGroupAmmunition
+	case CategoryDrone, CategoryFighter:
+		return RigGroupDrones
+	case CategoryStructure, CategoryStructureModule, CategoryStarbase:
+		return RigGroupStructures
+	case CategoryShip:
+		size, ok := shipSizes[product.GroupID]
+		if !ok {
+			return ""
+		}
+
+		if size == "capital" {
+			return RigGroupCapitalShips
+		}
+
+		// strategic cruisers are built with advanced ship rigs as well
+		if product.IsTechII() || product.IsTechIII() {
+			return advancedShipRigGroups[size]
+		}
+
+		return basicShipRigGroups[size]
+	}
+
+	return ""
+}
+
+// FacilityModifiers holds the modifiers a facility applies to a particular job
+type FacilityModifiers struct {
+	Material map[string]float64
+	Time     map[string]float64
+	Cost     float64
+}
+
+// NewDefaultFacility returns the facility that is assumed, if the caller did not choose one. It resembles
+// an unrigged Raitaru in high-sec.
+func NewDefaultFacility(solarSystemID int32, tax float64) *model.Facility {
+	return &model.Facility{
+		Name:          "Engineering Complex",
+		StructureType: model.StructureRaitaru,
+		SolarSystemID: solarSystemID,
+		Security:      model.SecurityHigh,
+		Tax:           tax,
+		Rigs:          model.FacilityRigs{},
+	}
+}
+
+// NewDefaultRefinery returns the facility that is assumed for reactions, if the caller did not choose one. It
+// resembles an unrigged Athanor in low-sec, since reactions cannot be run in high-sec.
+func NewDefaultRefinery(solarSystemID int32, tax float64) *model.Facility {
+	return &model.Facility{
+		Name:          "Refinery",
+		StructureType: model.StructureAthanor,
+		SolarSystemID: solarSystemID,
+		Security:      model.SecurityLow,
+		Tax:           tax,
+		Rigs:          model.FacilityRigs{},
+	}
+}
+
+// ValidateFacility checks whether the structure type, security band and rigs of a facility are known
+func ValidateFacility(facility *model.Facility) error {
+	if _, ok := FacilityStructures[facility.StructureType]; !ok {
+		return fmt.Errorf("unknown structure type %q", facility.StructureType)
+	}
+
+	if _, ok := SecurityMultipliers[facility.Security]; !ok {
+		return fmt.Errorf("unknown security band %q", facility.Security)
+	}
+
+	if facility.StructureType == model.StructureNPCStation && len(facility.Rigs) > 0 {
+		return fmt.Errorf("rigs cannot be installed in an NPC station")
+	}
+
+	for _, rig := range facility.Rigs {
+		if _, ok := RigGroupActivities[rig.Group]; !ok {
+			return fmt.Errorf("unknown rig group %q", rig.Group)
+		}
+
+		if _, ok := RigBonuses[rig.Bonus][rig.Tech]; !ok {
+			return fmt.Errorf("unknown rig %q with tech level %d", rig.Bonus, rig.Tech)
+		}
+	}
+
+	return nil
+}
+
+// NewFacilityModifiers derives the material, time and cost modifiers of a job with the specified activity and
+// product from the structure type, its rigs and the security band of the facility
+func NewFacilityModifiers(facility *model.Facility, activityID model.IndustryActivityID, product *model.Type) (modifiers *FacilityModifiers, err error) {
+	bonus, ok := FacilityStructures[facility.StructureType][activityID]
+	if !ok {
+		return nil, fmt.Errorf("%s cannot install %s jobs", facility.StructureType, model.IndustryActivityNames[activityID])
+	}
+
+	securityMultipliers := SecurityMultipliers
+	if activityID == ActivityReaction {
+		securityMultipliers = ReactionSecurityMultipliers
+	}
+
+	securityMultiplier, ok := securityMultipliers[facility.Security]
+	if !ok {
+		return nil, fmt.Errorf("%s jobs cannot be installed in %s-sec", model.IndustryActivityNames[activityID], facility.Security)
+	}
+
+	modifiers = &FacilityModifiers{
+		Material: map[string]float64{},
+		Time:     map[string]float64{},
+		Cost:     -bonus.Cost,
+	}
+
+	key := facility.StructureType + " Bonus"
+
+	if bonus.Material != 0 {
+		modifiers.Material[key] = -bonus.Material
+	}
+
+	if bonus.Time != 0 {
+		modifiers.Time[key] = -bonus.Time
+	}
+
+	group := RigGroup(product)
+	for _, rig := range facility.Rigs {
+		if rig.Group != group || RigGroupActivities[rig.Group] != activityID {
+			continue
+		}
+
+		key := fmt.Sprintf("T%d %s Rig (%s)", rig.Tech, rig.Bonus, rig.Group)
+		value := -RigBonuses[rig.Bonus][rig.Tech] * securityMultiplier
+
+		switch rig.Bonus {
+		case model.RigBonusMaterial:
+			modifiers.Material[key] += value
+		case model.RigBonusTime:
+			modifiers.Time[key] += value
+		}
+	}
+
+	return modifiers, nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oxisto/titan/model"
+)
+
+func TestRigGroup(t *testing.T) {
+	techII := int32(2)
+	techIII := int32(14)
+
+	tests := []struct {
+		name    string
+		product *model.Type
+		want    string
+	}{
+		{"module", &model.Type{GroupID: 55, Group: model.Group{CategoryID: CategoryModule}}, RigGroupEquipment},
+		{"charge", &model.Type{GroupID: 83, Group: model.Group{CategoryID: CategoryCharge}}, RigGroupAmmunition},
+		{"drone", &model.Type{GroupID: 100, Group: model.Group{CategoryID: CategoryDrone}}, RigGroupDrones},
+		{"frigate", &model.Type{GroupID: 25, Group: model.Group{CategoryID: CategoryShip}}, RigGroupBasicSmallShips},
+		{"assault frigate", &model.Type{GroupID: 324, MetaGroupID: &techII, Group: model.Group{CategoryID: CategoryShip}}, RigGroupAdvancedSmallShips},
+		{"strategic cruiser", &model.Type{GroupID: 963, MetaGroupID: &techIII, Group: model.Group{CategoryID: CategoryShip}}, RigGroupAdvancedMediumShips},
+		{"carrier", &model.Type{GroupID: 547, Group: model.Group{CategoryID: CategoryShip}}, RigGroupCapitalShips},
+		{"ship of unknown size", &model.Type{GroupID: 29, Group: model.Group{CategoryID: CategoryShip}}, ""},
+		{"advanced component", &model.Type{GroupID: 334, Group: model.Group{CategoryID: 17}}, RigGroupAdvancedComponents},
+		{"no rig applies", &model.Type{GroupID: 18, Group: model.Group{CategoryID: 4}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RigGroup(tt.product); got != tt.want {
+				t.Errorf("RigGroup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFacilityModifiers(t *testing.T) {
+	module := &model.Type{GroupID: 55, Group: model.Group{CategoryID: CategoryModule}}
+
+	type args struct {
+		facility   *model.Facility
+		activityID model.IndustryActivityID
+		product    *model.Type
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *FacilityModifiers
+		wantErr bool
+	}{
+		{
+			name: "unrigged Raitaru",
+			args: args{
+				facility:   &model.Facility{StructureType: model.StructureRaitaru, Security: model.SecurityHigh},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{"Raitaru Bonus": -0.01},
+				Time:     map[string]float64{"Raitaru Bonus": -0.15},
+				Cost:     -0.03,
+			},
+		},
+		{
+			name: "material rig in high-sec",
+			args: args{
+				facility: &model.Facility{StructureType: model.StructureRaitaru, Security: model.SecurityHigh, Rigs: model.FacilityRigs{
+					{Group: RigGroupEquipment, Bonus: model.RigBonusMaterial, Tech: 1},
+				}},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{"Raitaru Bonus": -0.01, "T1 material Rig (equipment)": -0.02},
+				Time:     map[string]float64{"Raitaru Bonus": -0.15},
+				Cost:     -0.03,
+			},
+		},
+		{
+			name: "rigs scaled by low-sec",
+			args: args{
+				facility: &model.Facility{StructureType: model.StructureRaitaru, Security: model.SecurityLow, Rigs: model.FacilityRigs{
+					{Group: RigGroupEquipment, Bonus: model.RigBonusMaterial, Tech: 1},
+					{Group: RigGroupEquipment, Bonus: model.RigBonusTime, Tech: 2},
+				}},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{"Raitaru Bonus": -0.01, "T1 material Rig (equipment)": -0.038},
+				Time:     map[string]float64{"Raitaru Bonus": -0.15, "T2 time Rig (equipment)": -0.456},
+				Cost:     -0.03,
+			},
+		},
+		{
+			name: "rigs scaled by null-sec",
+			args: args{
+				facility: &model.Facility{StructureType: model.StructureSotiyo, Security: model.SecurityNull, Rigs: model.FacilityRigs{
+					{Group: RigGroupEquipment, Bonus: model.RigBonusMaterial, Tech: 2},
+				}},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{"Sotiyo Bonus": -0.01, "T2 material Rig (equipment)": -0.0504},
+				Time:     map[string]float64{"Sotiyo Bonus": -0.30},
+				Cost:     -0.05,
+			},
+		},
+		{
+			name: "rig of another group",
+			args: args{
+				facility: &model.Facility{StructureType: model.StructureRaitaru, Security: model.SecurityHigh, Rigs: model.FacilityRigs{
+					{Group: RigGroupAmmunition, Bonus: model.RigBonusMaterial, Tech: 1},
+				}},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{"Raitaru Bonus": -0.01},
+				Time:     map[string]float64{"Raitaru Bonus": -0.15},
+				Cost:     -0.03,
+			},
+		},
+		{
+			name: "manufacturing rig for invention",
+			args: args{
+				facility: &model.Facility{StructureType: model.StructureRaitaru, Security: model.SecurityHigh, Rigs: model.FacilityRigs{
+					{Group: RigGroupEquipment, Bonus: model.RigBonusTime, Tech: 1},
+				}},
+				activityID: ActivityInvention,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{},
+				Time:     map[string]float64{"Raitaru Bonus": -0.15},
+				Cost:     -0.03,
+			},
+		},
+		{
+			name: "NPC station",
+			args: args{
+				facility:   &model.Facility{StructureType: model.StructureNPCStation, Security: model.SecurityHigh},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			want: &FacilityModifiers{
+				Material: map[string]float64{},
+				Time:     map[string]float64{},
+			},
+		},
+		{
+			name: "activity that cannot be installed",
+			args: args{
+				facility:   &model.Facility{StructureType: model.StructureAthanor, Security: model.SecurityLow},
+				activityID: ActivityManufacturing,
+				product:    module,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewFacilityModifiers(tt.args.facility, tt.args.activityID, tt.args.product)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewFacilityModifiers() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !equalModifiers(got.Material, tt.want.Material) {
+				t.Errorf("NewFacilityModifiers() Material = %v, want %v", got.Material, tt.want.Material)
+			}
+			if !equalModifiers(got.Time, tt.want.Time) {
+				t.Errorf("NewFacilityModifiers() Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			if math.Abs(got.Cost-tt.want.Cost) > 1e-9 {
+				t.Errorf("NewFacilityModifiers() Cost = %v, want %v", got.Cost, tt.want.Cost)
+			}
+		})
+	}
+}
+
+func equalModifiers(got map[string]float64, want map[string]float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+
+	for key, value := range want {
+		if v, ok := got[key]; !ok || math.Abs(v-value) > 1e-9 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"math"
+
+	"github.com/oxisto/titan/model"
+)
+
+const (
+	SkillIdAccounting      = 16622
+	SkillIdBrokerRelations = 3446
+)
+
+const (
+	// BaseSalesTax is the sales tax without any skills
+	BaseSalesTax = 0.075
+
+	// SalesTaxReductionPerLevel is the relative reduction of the sales tax per level of Accounting
+	SalesTaxReductionPerLevel = 0.11
+
+	// BaseBrokerFee is the broker fee in NPC stations without any skills or standings
+	BaseBrokerFee = 0.03
+
+	// MinBrokerFee is the lowest broker fee that NPC stations charge
+	MinBrokerFee = 0.01
+
+	// BrokerFeeReductionPerLevel is the reduction of the broker fee per level of Broker Relations
+	BrokerFeeReductionPerLevel = 0.003
+
+	// BrokerFeeReductionPerFactionStanding and BrokerFeeReductionPerCorporationStanding are the reductions
+	// of the broker fee per point of standing towards the owner of the station
+	BrokerFeeReductionPerFactionStanding     = 0.0003
+	BrokerFeeReductionPerCorporationStanding = 0.0002
+)
+
+// NewMarketFees derives the sales tax and broker fee from the skills of a character and its standings towards
+// the faction and the corporation that own the station of the trade hub
+func NewMarketFees(character SkillHolder, factionStanding float64, corporationStanding float64) *model.MarketFees {
+	fees := &model.MarketFees{}
+
+	fees.SalesTax = BaseSalesTax * (1 - SalesTaxReductionPerLevel*float64(skillLevel(character, SkillIdAccounting)))
+	fees.BrokerFee = BaseBrokerFee -
+		BrokerFeeReductionPerLevel*float64(skillLevel(character, SkillIdBrokerRelations)) -
+		BrokerFeeReductionPerFactionStanding*factionStanding -
+		BrokerFeeReductionPerCorporationStanding*corporationStanding
+	fees.BrokerFee = math.Max(fees.BrokerFee, MinBrokerFee)
+
+	return fees
+}
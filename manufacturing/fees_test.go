@@ -0,0 +1,59 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oxisto/titan/model"
+)
+
+// skillLevels is a SkillHolder with fixed skill levels. Skills that are missing are not trained.
+type skillLevels map[int32]int
+
+func (s skillLevels) SkillLevel(typeID int32) int {
+	return s[typeID]
+}
+
+func TestNewMarketFees(t *testing.T) {
+	type args struct {
+		character           SkillHolder
+		factionStanding     float64
+		corporationStanding float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want *model.MarketFees
+	}{
+		{"no skills", args{skillLevels{}, 0, 0}, &model.MarketFees{SalesTax: 0.075, BrokerFee: 0.03}},
+		{"all skills at level V", args{nil, 0, 0}, &model.MarketFees{SalesTax: 0.03375, BrokerFee: 0.015}},
+		{"some skills", args{skillLevels{SkillIdAccounting: 4, SkillIdBrokerRelations: 3}, 0, 0}, &model.MarketFees{SalesTax: 0.042, BrokerFee: 0.021}},
+		{"standings", args{nil, 5, 5}, &model.MarketFees{SalesTax: 0.03375, BrokerFee: 0.0125}},
+		{"negative standings", args{skillLevels{}, -5, 0}, &model.MarketFees{SalesTax: 0.075, BrokerFee: 0.0315}},
+		{"lowest broker fee", args{nil, 10, 10}, &model.MarketFees{SalesTax: 0.03375, BrokerFee: MinBrokerFee}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMarketFees(tt.args.character, tt.args.factionStanding, tt.args.corporationStanding)
+			if math.Abs(got.SalesTax-tt.want.SalesTax) > 1e-9 || math.Abs(got.BrokerFee-tt.want.BrokerFee) > 1e-9 {
+				t.Errorf("NewMarketFees() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
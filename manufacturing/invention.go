@@ -17,6 +17,7 @@ limitations under the License.
 package manufacturing
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -37,8 +38,28 @@ type SkillHolder interface {
 	SkillLevel(TypeID int32) int
 }
 
-func NewInvention(productTypeID int32, inventor SkillHolder) (invention *model.Invention, err error) {
-	blueprint := db.GetBlueprint(ActivityInvention, productTypeID).Blueprint
+// InventionJobCostFactor is the share of the product's estimated item value that is used as base for the invention job cost
+const InventionJobCostFactor = 0.02
+
+// CategoryAncientRelic is the category of the relics that Tech 3 blueprints are reverse engineered from
+const CategoryAncientRelic = 34
+
+// NewInvention calculates the costs of inventing the blueprint specified by productTypeID. Optionally, a
+// decryptor can be specified in the options, which modifies the probability as well as the runs, ME and TE
+// of the resulting blueprint.
+//
+// Tech 3 blueprints are reverse engineered from ancient relics instead, which uses the same activity. The relic
+// can be chosen with options.RelicTypeID, otherwise the one with the highest probability is used. In contrast
+// to a Tech 1 blueprint copy, the relic is consumed by every attempt.
+func NewInvention(productTypeID int32, inventor SkillHolder, options *Options) (invention *model.Invention, err error) {
+	if options == nil {
+		options = NewOptions()
+	}
+
+	var blueprint model.Blueprint
+	if blueprint, err = inventionBlueprint(productTypeID, options.RelicTypeID); err != nil {
+		return nil, err
+	}
 
 	invention = new(model.Invention)
 
@@ -46,20 +67,41 @@ func NewInvention(productTypeID int32, inventor SkillHolder) (invention *model.I
 		return nil, err
 	}
 
-	materials, err := db.GetActivityMaterials(ActivityInvention, blueprint, 1, 1)
+	invention.IsReverseEngineering = invention.BlueprintType.CategoryID == CategoryAncientRelic
+
+	if invention.Decryptor, err = GetDecryptor(options.DecryptorTypeID); err != nil {
+		return nil, err
+	}
+
+	invention.DecryptorTypeID = options.DecryptorTypeID
+
+	var materials []db.IndustryActivityMaterialResult
+	if materials, err = db.GetActivityMaterials(ActivityInvention, blueprint, 1, 1); err != nil {
+		return nil, fmt.Errorf("could not retrieve invention materials: %w", err)
+	}
 
 	var skills []db.IndustryActivitySkillResult
-	skills, err = db.GetActivitySkills(ActivityInvention, blueprint)
+	if skills, err = db.GetActivitySkills(ActivityInvention, blueprint); err != nil {
+		return nil, fmt.Errorf("could not retrieve invention skills: %w", err)
+	}
 
 	typeIDs := []int32{}
 	for _, material := range materials {
 		typeIDs = append(typeIDs, material.TypeID)
 	}
 
+	if invention.Decryptor != nil {
+		typeIDs = append(typeIDs, invention.Decryptor.TypeID)
+	}
+
+	if invention.IsReverseEngineering {
+		typeIDs = append(typeIDs, blueprint.TypeID)
+	}
+
 	// make sure, prices are available
 	var prices map[int32]model.Price
 
-	if prices, err = cache.GetPrices(model.JitaRegionID, typeIDs); err != nil {
+	if prices, err = cache.GetPrices(options.Hub, typeIDs); err != nil {
 		return nil, err
 	}
 
@@ -86,13 +128,68 @@ func NewInvention(productTypeID int32, inventor SkillHolder) (invention *model.I
 	invention.CostsPerRun = 0
 	invention.Materials = map[string]model.ManufacturingMaterial{}
 	for _, material := range materials {
-		material.PricePerUnit = prices[material.TypeID].Sell.Percentile
+		material.PricePerUnit = prices[material.TypeID].Value(options.MaterialStrategy)
 		material.Cost = float64(material.Quantity) * material.PricePerUnit
 
 		invention.Materials[strconv.Itoa(int(material.TypeID))] = material.ManufacturingMaterial
 		invention.CostsPerRun += material.Cost
 	}
 
+	// the decryptor is consumed on every invention attempt, so it is treated like a material
+	if invention.Decryptor != nil {
+		material := model.ManufacturingMaterial{
+			TypeID:       invention.Decryptor.TypeID,
+			TypeName:     invention.Decryptor.TypeName,
+			Quantity:     1,
+			RawQuantity:  1,
+			PricePerUnit: prices[invention.Decryptor.TypeID].Value(options.MaterialStrategy),
+		}
+		material.Cost = material.PricePerUnit
+
+		invention.Materials[strconv.Itoa(int(material.TypeID))] = material
+		invention.CostsPerRun += material.Cost
+	}
+
+	// the same applies to relics, which are lost no matter if reverse engineering succeeds
+	if invention.IsReverseEngineering {
+		material := model.ManufacturingMaterial{
+			TypeID:       blueprint.TypeID,
+			TypeName:     invention.BlueprintType.TypeName,
+			Quantity:     1,
+			RawQuantity:  1,
+			PricePerUnit: prices[blueprint.TypeID].Value(options.MaterialStrategy),
+		}
+		material.Cost = material.PricePerUnit
+
+		invention.Materials[strconv.Itoa(int(material.TypeID))] = material
+		invention.CostsPerRun += material.Cost
+	}
+
+	// the job cost is based on the estimated item value of the product that the invented blueprint produces
+	var (
+		eiv   float64
+		index *model.SystemCostIndex
+	)
+
+	if eiv, err = EstimatedItemValue(ActivityManufacturing, model.Blueprint{TypeID: productTypeID}); err != nil {
+		return nil, err
+	}
+
+	facility := options.facility()
+
+	var facilityModifiers *FacilityModifiers
+	if facilityModifiers, err = NewFacilityModifiers(facility, ActivityInvention, invention.BlueprintType); err != nil {
+		return nil, err
+	}
+
+	if index, err = cache.GetSystemCostIndex(facility.SolarSystemID); err != nil {
+		return nil, err
+	}
+
+	invention.SystemCostIndex = index.CostIndex(ActivityInvention)
+	invention.JobCost = CalculateJobCost(eiv*InventionJobCostFactor, invention.SystemCostIndex, facilityModifiers.Cost, facility.Tax)
+	invention.CostsPerRun += invention.JobCost
+
 	// we just the the first product. the probability should be the same for all anyway
 	invention.SuccessProbabilityModifiers = map[string]float64{}
 
@@ -101,6 +198,16 @@ func NewInvention(productTypeID int32, inventor SkillHolder) (invention *model.I
 		return nil, err
 	}
 
+	var product db.IndustryActivityProductResult
+	if product, err = db.GetActivityProduct(ActivityInvention, blueprint.TypeID, productTypeID); err != nil {
+		return nil, err
+	}
+
+	// invented and reverse engineered blueprints always start at ME 2 and TE 4, the runs depend on the relic
+	invention.Runs = product.Quantity
+	invention.ME = 2
+	invention.TE = 4
+
 	invention.SuccessProbabilityModifiers["Blueprint Base Probability"] = result.Probability
 	invention.SuccessProbabilityModifiers["Skills"] = 0
 	for _, skillMod := range skillMods {
@@ -108,8 +215,75 @@ func NewInvention(productTypeID int32, inventor SkillHolder) (invention *model.I
 	}
 
 	invention.InventionChance = invention.SuccessProbabilityModifiers["Blueprint Base Probability"] * (1 + invention.SuccessProbabilityModifiers["Skills"])
+
+	if invention.Decryptor != nil {
+		invention.SuccessProbabilityModifiers["Decryptor"] = invention.Decryptor.ProbabilityMultiplier - 1
+		invention.InventionChance *= invention.Decryptor.ProbabilityMultiplier
+
+		invention.Runs += invention.Decryptor.MaxRunModifier
+		invention.ME += invention.Decryptor.MEModifier
+		invention.TE += invention.Decryptor.TEModifier
+	}
+
 	invention.TriesForManufacturing = 1 / invention.InventionChance
 	invention.CostsForManufacturing = invention.CostsPerRun * invention.TriesForManufacturing
 
 	return invention, nil
 }
+
+// inventionBlueprint returns the blueprint that the specified blueprint is invented from. For Tech 3 blueprints,
+// this is the specified relic or, if none is specified, the relic with the highest probability.
+func inventionBlueprint(productTypeID int32, relicTypeID int32) (blueprint model.Blueprint, err error) {
+	var blueprints []db.BlueprintResult
+	if blueprints, err = db.GetBlueprints(ActivityInvention, productTypeID); err != nil {
+		return blueprint, err
+	}
+
+	if len(blueprints) == 0 {
+		return blueprint, fmt.Errorf("blueprint %d cannot be invented", productTypeID)
+	}
+
+	bestProbability := -1.0
+	for _, b := range blueprints {
+		if relicTypeID != 0 {
+			if b.TypeID == relicTypeID {
+				return b.Blueprint, nil
+			}
+
+			continue
+		}
+
+		var result db.IndustryActivityProbabilityResult
+		if result, err = db.GetActivityProbablity(ActivityInvention, b.TypeID, productTypeID); err != nil {
+			return blueprint, err
+		}
+
+		if result.Probability > bestProbability {
+			blueprint = b.Blueprint
+			bestProbability = result.Probability
+		}
+	}
+
+	if relicTypeID != 0 {
+		return blueprint, fmt.Errorf("blueprint %d cannot be reverse engineered from %d", productTypeID, relicTypeID)
+	}
+
+	return blueprint, nil
+}
+
+// RelicTypeIDs returns the type IDs of all relics that the blueprint of a Tech 3 product can be reverse engineered from
+func RelicTypeIDs(productTypeID int32) (typeIDs []int32, err error) {
+	blueprint := db.GetBlueprint(ActivityManufacturing, productTypeID).Blueprint
+
+	var blueprints []db.BlueprintResult
+	if blueprints, err = db.GetBlueprints(ActivityInvention, blueprint.TypeID); err != nil {
+		return nil, err
+	}
+
+	typeIDs = []int32{}
+	for _, b := range blueprints {
+		typeIDs = append(typeIDs, b.TypeID)
+	}
+
+	return typeIDs, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// InventoryOptions specify where owned materials are taken from, instead of buying them
+type InventoryOptions struct {
+	CorporationID int32
+	// LocationID is the station, structure or container the materials are stored in
+	LocationID int64
+	// Division is the hangar division of the materials. If it is 0, all divisions are used.
+	Division int32
+	// Valuation specifies the value of the materials on hand
+	Valuation model.InventoryValuation
+}
+
+// inventory keeps track of the owned materials that are still available
+type inventory struct {
+	quantities       map[int32]int
+	acquisitionCosts map[int32]float64
+	valuation        model.InventoryValuation
+}
+
+func newInventory(options *InventoryOptions, typeIDs []int32) (inv *inventory, err error) {
+	inv = &inventory{
+		valuation: options.Valuation,
+	}
+
+	if inv.quantities, err = db.GetAssetQuantities(options.CorporationID, options.LocationID, options.Division, typeIDs); err != nil {
+		return nil, fmt.Errorf("could not retrieve inventory: %w", err)
+	}
+
+	if inv.valuation == model.InventoryValuationAcquisition {
+		if inv.acquisitionCosts, err = db.GetAverageBuyPrices(options.CorporationID, typeIDs); err != nil {
+			return nil, fmt.Errorf("could not retrieve acquisition costs: %w", err)
+		}
+	}
+
+	return inv, nil
+}
+
+// use takes up to quantity units of a material out of the inventory and returns how many units were taken
+func (inv *inventory) use(typeID int32, quantity int) int {
+	used := inv.quantities[typeID]
+	if used > quantity {
+		used = quantity
+	}
+
+	inv.quantities[typeID] -= used
+
+	return used
+}
+
+// value returns the value of a single unit of an owned material. Materials that we have no record of buying,
+// for example because they were mined or built, are valued at the market price.
+func (inv *inventory) value(typeID int32, marketPrice float64) float64 {
+	if cost, ok := inv.acquisitionCosts[typeID]; ok && inv.valuation == model.InventoryValuationAcquisition {
+		return cost
+	}
+
+	return marketPrice
+}
+
+// NewShoppingList creates a shopping list out of all materials that still need to be bought
+func NewShoppingList(materials map[string]model.ManufacturingMaterial) *model.ShoppingList {
+	list := &model.ShoppingList{
+		Items: []model.ShoppingListItem{},
+	}
+
+	for _, material := range materials {
+		if material.ToBuy <= 0 {
+			continue
+		}
+
+		item := model.ShoppingListItem{
+			TypeID:       material.TypeID,
+			TypeName:     material.TypeName,
+			Quantity:     material.ToBuy,
+			PricePerUnit: material.PricePerUnit,
+			Cost:         float64(material.ToBuy) * material.PricePerUnit,
+			Volume:       float64(material.ToBuy) * material.Volume,
+		}
+
+		list.Items = append(list.Items, item)
+		list.TotalCost += item.Cost
+		list.TotalVolume += item.Volume
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].TypeName < list.Items[j].TypeName
+	})
+
+	return list
+}
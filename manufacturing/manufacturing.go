@@ -29,110 +29,351 @@ import (
 )
 
 const (
-	SkillIdIndustry         = 3380
-	SkillIdAdvancedIndustry = 3388
+	SkillIdIndustry               = 3380
+	SkillIdScience                = 3402
+	SkillIdResearch               = 3403
+	SkillIdMetallurgy             = 3409
+	SkillIdAdvancedIndustry       = 3388
+	SkillIdMassProduction         = 3387
+	SkillIdAdvancedMassProduction = 24625
+	SkillIdReactions              = 45746
+	SkillIdMassReactions          = 45748
+	SkillIdAdvancedMassReactions  = 45749
 )
 
 const (
 	ActivityManufacturing = model.IndustryActivityID(1)
+	ActivityResearchTE    = model.IndustryActivityID(3)
+	ActivityResearchME    = model.IndustryActivityID(4)
+	ActivityCopying       = model.IndustryActivityID(5)
 	ActivityInvention     = model.IndustryActivityID(8)
+	ActivityReaction      = model.IndustryActivityID(11)
 )
 
-var FacilityJobDurationBonuses = map[string]float64{
-	"Station":             0,
-	"Engineering Complex": -0.15,
+// Options contains all parameters of a manufacturing calculation that can be chosen by the caller
+type Options struct {
+	ME                int64
+	TE                int64
+	FacilityTax       float64
+	SolarSystemID     int32
+	Facility          *model.Facility
+	ReactionFacility  *model.Facility
+	DecryptorTypeID   int32
+	OptimizeDecryptor bool
+	TargetME          int64
+	TargetTE          int64
+	Copies            int
+	CopyRuns          int
+	// RelicTypeID is the relic that Tech 3 blueprints are reverse engineered from. If it is 0, the relic with the highest probability is used.
+	RelicTypeID int32
+	// CorporationID is the corporation whose blueprints are used. If it is set, ME, TE and runs are taken from the best owned blueprint instead.
+	CorporationID int32
+	// Hub is the trade hub whose prices are used for materials and products
+	Hub model.TradeHub
+	// MaterialStrategy and ProductStrategy specify how materials are bought and products are sold
+	MaterialStrategy model.PriceStrategy
+	ProductStrategy  model.PriceStrategy
+	// Fees are the market fees. If they are not set, they are derived from the builder's skills and the standings.
+	Fees                *model.MarketFees
+	FactionStanding     float64
+	CorporationStanding float64
+	// Hauling is the rate for hauling materials from the trade hub to the facility and the products back
+	Hauling model.HaulingRate
+	// BuildDecisions overrides whether a material in a build tree is built (true) or bought (false)
+	BuildDecisions map[int32]bool
+	// Inventory specifies where owned materials are taken from. If it is nil, all materials are bought.
+	Inventory *InventoryOptions
+	// Runs is the number of runs of each job. If it is 0, a single run is used, unless the runs are determined by
+	// a blueprint copy. Jobs is the number of jobs that are run in parallel.
+	Runs int
+	Jobs int
 }
 
-var FacilityMaterialBonuses = map[string]float64{
-	"Station":             0,
-	"Engineering Complex": -0.01,
+// DefaultSolarSystemID is the solar system whose cost indices are used, if the caller does not specify one
+var DefaultSolarSystemID int32 = model.JitaSystemID
+
+// DefaultTradeHub is the trade hub whose prices are used, if the caller does not specify one
+var DefaultTradeHub = model.TradeHubs[model.TradeHubJita]
+
+// NewOptions returns the default manufacturing options, which assume a fully researched blueprint
+func NewOptions() *Options {
+	options := &Options{}
+	options.ME = 10
+	options.TE = 20
+	options.FacilityTax = 0.1
+	options.SolarSystemID = DefaultSolarSystemID
+	options.DecryptorTypeID = NoDecryptor
+	options.TargetME = 10
+	options.TargetTE = 20
+	options.Hub = DefaultTradeHub
+	options.MaterialStrategy = model.PriceStrategySell
+	options.ProductStrategy = model.PriceStrategySell
+	options.Jobs = 1
+
+	return options
 }
 
-var FacilityISKBonus = map[string]float64{
-	"Station":             0,
-	"Engineering Complex": -0.03,
+// runs returns the runs of a job, if the blueprint does not limit them
+func (options *Options) runs() int {
+	if options.Runs < 1 {
+		return 1
+	}
+
+	return options.Runs
+}
+
+// facility returns the facility of the options. If none was chosen, the default facility is built out of
+// the solar system and tax in the options.
+func (options *Options) facility() *model.Facility {
+	if options.Facility != nil {
+		return options.Facility
+	}
+
+	return NewDefaultFacility(options.SolarSystemID, options.FacilityTax)
+}
+
+// facilityFor returns the facility in which jobs of the specified activity are installed. Reactions cannot
+// be run in engineering complexes, so they have their own facility.
+func (options *Options) facilityFor(activityID model.IndustryActivityID) *model.Facility {
+	if activityID != ActivityReaction {
+		return options.facility()
+	}
+
+	if options.ReactionFacility != nil {
+		return options.ReactionFacility
+	}
+
+	return NewDefaultRefinery(options.SolarSystemID, options.FacilityTax)
+}
+
+// ownedBlueprint returns the best blueprint of the specified type that is owned by the corporation in the options.
+// It returns nil, if no corporation is specified or the corporation does not own such a blueprint.
+func (options *Options) ownedBlueprint(blueprintTypeID int32) (blueprint *model.CorporationBlueprint, err error) {
+	if options.CorporationID == 0 {
+		return nil, nil
+	}
+
+	if blueprint, err = db.GetBestCorporationBlueprint(options.CorporationID, blueprintTypeID); err != nil {
+		return nil, fmt.Errorf("could not retrieve owned blueprints: %w", err)
+	}
+
+	return blueprint, nil
+}
+
+// skillLevel returns the level of a skill of the builder. If no builder is specified, all skills are assumed to be at level 5.
+func skillLevel(builder SkillHolder, skillID int32) int {
+	if builder == nil {
+		return 5
+	}
+
+	return builder.SkillLevel(skillID)
+}
+
+// maxSlots returns the number of jobs of the specified activity the builder can run in parallel
+func maxSlots(builder SkillHolder, activityID model.IndustryActivityID) int {
+	if activityID == ActivityReaction {
+		return 1 + skillLevel(builder, SkillIdMassReactions) + skillLevel(builder, SkillIdAdvancedMassReactions)
+	}
+
+	return 1 + skillLevel(builder, SkillIdMassProduction) + skillLevel(builder, SkillIdAdvancedMassProduction)
+}
+
+// skillTimeModifier returns the job duration modifier the skills of the builder grant for the specified activity
+func skillTimeModifier(builder SkillHolder, activityID model.IndustryActivityID) float64 {
+	switch activityID {
+	case ActivityManufacturing:
+		return -0.04*float64(skillLevel(builder, SkillIdIndustry)) - 0.03*float64(skillLevel(builder, SkillIdAdvancedIndustry)-1)
+	case ActivityReaction:
+		return -0.04 * float64(skillLevel(builder, SkillIdReactions))
+	case ActivityResearchTE:
+		return -0.05*float64(skillLevel(builder, SkillIdResearch)) - 0.03*float64(skillLevel(builder, SkillIdAdvancedIndustry))
+	case ActivityResearchME:
+		return -0.05*float64(skillLevel(builder, SkillIdMetallurgy)) - 0.03*float64(skillLevel(builder, SkillIdAdvancedIndustry))
+	case ActivityCopying:
+		return -0.05*float64(skillLevel(builder, SkillIdScience)) - 0.03*float64(skillLevel(builder, SkillIdAdvancedIndustry))
+	}
+
+	return 0
+}
+
+// requiredSkills checks the skills of the builder against the skills required for a job
+func requiredSkills(builder SkillHolder, skills []db.IndustryActivitySkillResult) (required map[string]model.ManufacturingSkill, hasRequiredSkills bool) {
+	required = map[string]model.ManufacturingSkill{}
+	hasRequiredSkills = true
+
+	for _, skill := range skills {
+		skill.SkillLevel = skillLevel(builder, skill.TypeID)
+		skill.HasLearned = skill.SkillLevel >= skill.RequiredLevel
+
+		if !skill.HasLearned {
+			hasRequiredSkills = false
+		}
+
+		required[strconv.Itoa(int(skill.TypeID))] = skill.ManufacturingSkill
+	}
+
+	return
 }
 
+// CalculateModifier combines all modifiers into one factor. Bonuses from skills, blueprints, structures
+// and rigs all stack multiplicatively.
 func CalculateModifier(modifiers map[string]float64) float64 {
 	f := 1.0
 
 	for _, mod := range modifiers {
-		f += 1 * mod
+		f *= 1 + mod
 	}
 
 	return f
 }
 
-func NewManufacturing(builder SkillHolder, productTypeID int32, ME int64, TE int64, facilityTax float64, object model.CachedObject) (err error) {
+func NewManufacturing(builder SkillHolder, productTypeID int32, options *Options, object model.CachedObject) (err error) {
 	manufacturing, ok := object.(*model.Manufacturing)
 	if !ok {
 		return errors.New("passing invalid type to NewManufacturing function")
 	}
 
+	if options == nil {
+		options = NewOptions()
+	}
+
+	if options.OptimizeDecryptor {
+		return newManufacturingWithOptimalDecryptor(builder, productTypeID, options, manufacturing)
+	}
+
 	manufacturing.ProductTypeID = productTypeID
 	if manufacturing.Product, err = db.GetType(productTypeID); err != nil {
 		return fmt.Errorf("could not retrieve type: %w", err)
 	}
 
+	// products are either manufactured or, in case of moon materials and polymers, the result of a reaction
+	activityID := ActivityManufacturing
 	blueprint := db.GetBlueprint(ActivityManufacturing, productTypeID).Blueprint
 
+	if blueprint.TypeID == 0 {
+		activityID = ActivityReaction
+		blueprint = db.GetBlueprint(ActivityReaction, productTypeID).Blueprint
+	}
+
 	if blueprint.TypeID == 0 {
 		return errors.New("item cannot be manufactured")
 	}
 
+	manufacturing.ActivityID = activityID
+
 	if manufacturing.BlueprintType, err = db.GetType(blueprint.TypeID); err != nil {
 		return fmt.Errorf("could not retrieve type: %w", err)
 	}
 
-	industrySkillLevel := 5
-	advancedIndustrySkillLevel := 5
+	var product db.IndustryActivityProductResult
+	if product, err = db.GetActivityProduct(activityID, blueprint.TypeID, productTypeID); err != nil {
+		return err
+	}
 
-	if builder != nil {
-		industrySkillLevel = builder.SkillLevel(SkillIdIndustry)
-		advancedIndustrySkillLevel = builder.SkillLevel(SkillIdAdvancedIndustry)
+	manufacturing.UnitsPerRun = product.Quantity
+	if manufacturing.UnitsPerRun < 1 {
+		manufacturing.UnitsPerRun = 1
 	}
 
-	if manufacturing.Product.IsTechII() {
-		manufacturing.IsTech2 = true
-		if manufacturing.Invention, err = NewInvention(blueprint.TypeID, builder); err != nil {
+	if manufacturing.OwnedBlueprint, err = options.ownedBlueprint(blueprint.TypeID); err != nil {
+		return err
+	}
+
+	manufacturing.HasBlueprint = options.CorporationID == 0 || manufacturing.OwnedBlueprint != nil
+	manufacturing.IsTech2 = manufacturing.Product.IsTechII()
+	manufacturing.IsTech3 = manufacturing.Product.IsTechIII()
+
+	if activityID == ActivityReaction {
+		// reaction formulas cannot be researched
+		manufacturing.Invention = &model.Invention{}
+		manufacturing.IsTech2 = false
+		manufacturing.IsTech3 = false
+		manufacturing.Runs = options.runs()
+	} else if manufacturing.OwnedBlueprint != nil {
+		// no need to invent anything, if we already own the blueprint
+		manufacturing.Invention = &model.Invention{}
+		manufacturing.Runs = options.runs()
+		manufacturing.ME = int64(manufacturing.OwnedBlueprint.MaterialEfficiency)
+		manufacturing.TE = int64(manufacturing.OwnedBlueprint.TimeEfficiency)
+
+		// a copy cannot be run more often than it has runs left
+		if copyRuns := int(manufacturing.OwnedBlueprint.Runs); manufacturing.OwnedBlueprint.IsCopy() && (options.Runs < 1 || options.Runs > copyRuns) {
+			manufacturing.Runs = copyRuns
+		}
+	} else if manufacturing.IsTech2 || manufacturing.IsTech3 {
+		if manufacturing.Invention, err = NewInvention(blueprint.TypeID, builder, options); err != nil {
 			return fmt.Errorf("could not invent type: %w", err)
 		}
-		manufacturing.Runs = blueprint.MaxProductionLimit
 
-		// TODO: decryptors
-		manufacturing.ME = 2
-		manufacturing.TE = 4
+		// the invented blueprint copy determines runs, ME and TE
+		manufacturing.Runs = manufacturing.Invention.Runs
+		manufacturing.ME = manufacturing.Invention.ME
+		manufacturing.TE = manufacturing.Invention.TE
+
+		// relics can be bought, but Tech 1 blueprints need to be owned to invent from them
+		if !manufacturing.HasBlueprint && manufacturing.Invention.IsReverseEngineering {
+			manufacturing.HasBlueprint = true
+		} else if !manufacturing.HasBlueprint {
+			var source *model.CorporationBlueprint
+			if source, err = options.ownedBlueprint(manufacturing.Invention.BlueprintType.TypeID); err != nil {
+				return err
+			}
+
+			manufacturing.HasBlueprint = source != nil
+		}
 	} else {
 		// to avoid NPE
 		manufacturing.Invention = &model.Invention{}
 		manufacturing.IsTech2 = false
-		manufacturing.Runs = 1
-		manufacturing.ME = ME
-		manufacturing.TE = TE
+		manufacturing.Runs = options.runs()
+		manufacturing.ME = options.ME
+		manufacturing.TE = options.TE
 	}
 
-	manufacturing.Facility = "Engineering Complex" // TODO: from options
-	manufacturing.MaxSlots = 1 + industrySkillLevel + advancedIndustrySkillLevel
+	facility := options.facilityFor(activityID)
+
+	var facilityModifiers *FacilityModifiers
+	if facilityModifiers, err = NewFacilityModifiers(facility, activityID, manufacturing.Product); err != nil {
+		return err
+	}
+
+	manufacturing.Facility = facility.Name
+	manufacturing.FacilityID = facility.FacilityID
+	manufacturing.MaxSlots = maxSlots(builder, activityID)
 
 	manufacturing.JobDurationModifiers = map[string]float64{}
-	manufacturing.JobDurationModifiers["Skills"] = -0.04*float64(industrySkillLevel) - 0.03*float64(advancedIndustrySkillLevel-1)
+	manufacturing.JobDurationModifiers["Skills"] = skillTimeModifier(builder, activityID)
 	manufacturing.JobDurationModifiers["Blueprint Time Efficiency"] = -float64(manufacturing.TE) / 100
-	manufacturing.JobDurationModifiers[manufacturing.Facility+" Bonus"] = FacilityJobDurationBonuses[manufacturing.Facility]
+	for key, mod := range facilityModifiers.Time {
+		manufacturing.JobDurationModifiers[key] = mod
+	}
 
 	manufacturing.MaterialConsumptionModifiers = map[string]float64{}
 	manufacturing.MaterialConsumptionModifiers["Blueprint Material Efficiency"] = -float64(manufacturing.ME) / 100
-	manufacturing.MaterialConsumptionModifiers[manufacturing.Facility+" Bonus"] = FacilityMaterialBonuses[manufacturing.Facility]
+	for key, mod := range facilityModifiers.Material {
+		manufacturing.MaterialConsumptionModifiers[key] = mod
+	}
 
 	manufacturing.TimeModifier = CalculateModifier(manufacturing.JobDurationModifiers)
 	manufacturing.MaterialModifier = CalculateModifier(manufacturing.MaterialConsumptionModifiers)
 
+	manufacturing.Jobs = options.Jobs
+	if manufacturing.Jobs < 1 {
+		manufacturing.Jobs = 1
+	}
+
+	// materials are rounded per job, so parallel jobs need more materials than a single job with all runs
 	var materials []db.IndustryActivityMaterialResult
-	if materials, err = db.GetActivityMaterials(ActivityManufacturing, blueprint, manufacturing.Runs, manufacturing.MaterialModifier); err != nil {
+	if materials, err = db.GetActivityMaterials(activityID, blueprint, manufacturing.Runs, manufacturing.MaterialModifier); err != nil {
 		return err
 	}
 
+	for i := range materials {
+		materials[i].Quantity *= manufacturing.Jobs
+	}
+
 	var skills []db.IndustryActivitySkillResult
-	if skills, err = db.GetActivitySkills(ActivityManufacturing, blueprint); err != nil {
+	if skills, err = db.GetActivitySkills(activityID, blueprint); err != nil {
 		return err
 	}
 
@@ -146,19 +387,43 @@ func NewManufacturing(builder SkillHolder, productTypeID int32, ME int64, TE int
 	// make sure, prices are available
 	var prices map[int32]model.Price
 
-	if prices, err = cache.GetPrices(model.JitaRegionID, typeIDs); err != nil {
+	if prices, err = cache.GetPrices(options.Hub, typeIDs); err != nil {
 		return err
 	}
 
+	manufacturing.Hub = options.Hub
+	manufacturing.MaterialStrategy = options.MaterialStrategy
+	manufacturing.ProductStrategy = options.ProductStrategy
+
+	var inv *inventory
+	if options.Inventory != nil {
+		if inv, err = newInventory(options.Inventory, typeIDs); err != nil {
+			return err
+		}
+	}
+
 	eiv := 0.0
+	materialVolume := 0.0
+	boughtMaterials := 0.0
 
 	manufacturing.Materials = map[string]model.ManufacturingMaterial{}
 	for _, material := range materials {
-		material.PricePerUnit = prices[material.TypeID].Sell.Percentile
-		material.Cost = float64(material.Quantity) * material.PricePerUnit
+		material.PricePerUnit = prices[material.TypeID].Value(options.MaterialStrategy)
+		material.ToBuy = material.Quantity
+
+		// materials on hand are not bought, but they still have a value
+		if inv != nil {
+			material.OnHand = inv.use(material.TypeID, material.Quantity)
+			material.ToBuy -= material.OnHand
+			material.OnHandValue = float64(material.OnHand) * inv.value(material.TypeID, material.PricePerUnit)
+		}
+
+		material.Cost = float64(material.ToBuy)*material.PricePerUnit + material.OnHandValue
 
 		manufacturing.Materials[strconv.Itoa(int(material.TypeID))] = material.ManufacturingMaterial
 		manufacturing.Costs.TotalMaterials += material.Cost
+		boughtMaterials += float64(material.ToBuy) * material.PricePerUnit
+		materialVolume += float64(material.ToBuy) * material.Volume
 
 		// get market prices for Estimated Item Value (EIV) calculation
 		if marketPrice, err := cache.GetMarketPrice(material.TypeID); err != nil {
@@ -171,77 +436,217 @@ func NewManufacturing(builder SkillHolder, productTypeID int32, ME int64, TE int
 
 	eiv = /*math.Round(*/ eiv * float64(manufacturing.Runs) /*)*/
 
-	manufacturing.RequiredSkills = map[string]model.ManufacturingSkill{}
-	manufacturing.HasRequiredSkills = true
-	for _, skill := range skills {
-		skill.SkillLevel = 5
-		if builder != nil {
-			skill.SkillLevel = builder.SkillLevel(skill.TypeID)
-		}
-		skill.HasLearned = skill.SkillLevel >= skill.RequiredLevel
+	jobs := float64(manufacturing.Jobs)
 
-		if !skill.HasLearned {
-			manufacturing.HasRequiredSkills = false
-		}
+	manufacturing.RequiredSkills, manufacturing.HasRequiredSkills = requiredSkills(builder, skills)
 
-		manufacturing.RequiredSkills[strconv.Itoa(int(skill.TypeID))] = skill.ManufacturingSkill
+	var index *model.SystemCostIndex
+	if index, err = cache.GetSystemCostIndex(facility.SolarSystemID); err != nil {
+		return fmt.Errorf("could not retrieve system cost index: %w", err)
 	}
 
-	manufacturing.Costs.TotalJobCost = CalculateJobCost(eiv, 0.0386, FacilityISKBonus[manufacturing.Facility], facilityTax)
+	manufacturing.SolarSystemID = facility.SolarSystemID
+	manufacturing.SystemCostIndex = index.CostIndex(activityID)
+	manufacturing.Costs.TotalJobCost = CalculateJobCost(eiv, manufacturing.SystemCostIndex, facilityModifiers.Cost, facility.Tax) * jobs
+
+	fees := options.Fees
+	if fees == nil {
+		fees = NewMarketFees(builder, options.FactionStanding, options.CorporationStanding)
+	}
+
+	units := float64(manufacturing.UnitsPerRun*manufacturing.Runs) * jobs
+	productPrice := prices[productTypeID]
+
+	manufacturing.Fees = *fees
+	manufacturing.Hauling = options.Hauling
 
-	manufacturing.Costs.Total = manufacturing.Costs.TotalMaterials + manufacturing.Costs.TotalJobCost
-	manufacturing.Costs.PerItem = manufacturing.Costs.Total / float64(manufacturing.Product.PortionSize) / float64(manufacturing.Runs)
+	// placing buy orders for materials costs broker fees
+	manufacturing.Costs.TotalFees = boughtMaterials * fees.BuyFee(options.MaterialStrategy)
 
-	if manufacturing.IsTech2 {
-		manufacturing.Costs.Total += manufacturing.Invention.CostsForManufacturing
+	// bought materials are hauled from the trade hub to the facility and the products back to the trade hub
+	manufacturing.Costs.TotalHauling = options.Hauling.Cost(materialVolume, boughtMaterials) +
+		options.Hauling.Cost(manufacturing.Product.Volume*units, productPrice.Value(options.ProductStrategy)*units)
+
+	manufacturing.Costs.Total = manufacturing.Costs.TotalMaterials + manufacturing.Costs.TotalJobCost +
+		manufacturing.Costs.TotalFees + manufacturing.Costs.TotalHauling
+
+	if manufacturing.Invention.BlueprintType != nil {
+		// every job needs its own invented blueprint copy
+		manufacturing.Costs.Total += manufacturing.Invention.CostsForManufacturing * jobs
+	}
+
+	manufacturing.Costs.PerItem = manufacturing.Costs.Total / units
+
+	if inv != nil {
+		manufacturing.ShoppingList = NewShoppingList(manufacturing.Materials)
 	}
 
 	var activity db.IndustryActivityResult
-	if activity, err = db.GetIndustryActivity(blueprint.TypeID, ActivityManufacturing); err != nil {
+	if activity, err = db.GetIndustryActivity(blueprint.TypeID, activityID); err != nil {
 		return err
 	}
 
 	manufacturing.Time = int(math.Ceil(float64(activity.Time*manufacturing.Runs) * manufacturing.TimeModifier))
 	manufacturing.SlotsUsed = manufacturing.MaxSlots
-	manufacturing.ItemsPerDay = 3600.0 / float64(manufacturing.Time/manufacturing.Runs) * 24.0 * float64(manufacturing.Product.PortionSize) * float64(manufacturing.SlotsUsed)
+	manufacturing.ItemsPerDay = 3600.0 / float64(manufacturing.Time/manufacturing.Runs) * 24.0 * float64(manufacturing.UnitsPerRun) * float64(manufacturing.SlotsUsed)
 
 	// revenue
+	manufacturing.Revenue.PerItem = model.ProfitValue{
+		BasedOnBuyPrice:  productPrice.Buy.Percentile,
+		BasedOnSellPrice: productPrice.Sell.Percentile,
+		BasedOnStrategy:  productPrice.Value(options.ProductStrategy),
+	}
 	manufacturing.Revenue.Total = model.ProfitValue{
-		BasedOnBuyPrice:  prices[productTypeID].Buy.Percentile * float64(manufacturing.Product.PortionSize*manufacturing.Runs),
-		BasedOnSellPrice: prices[productTypeID].Sell.Percentile * float64(manufacturing.Product.PortionSize*manufacturing.Runs),
+		BasedOnBuyPrice:  manufacturing.Revenue.PerItem.BasedOnBuyPrice * units,
+		BasedOnSellPrice: manufacturing.Revenue.PerItem.BasedOnSellPrice * units,
+		BasedOnStrategy:  manufacturing.Revenue.PerItem.BasedOnStrategy * units,
 	}
-	manufacturing.Revenue.PerItem = model.ProfitValue{
-		BasedOnBuyPrice:  prices[productTypeID].Buy.Percentile,
-		BasedOnSellPrice: prices[productTypeID].Sell.Percentile,
+
+	// selling to buy orders only costs sales tax, placing sell orders costs broker fees as well
+	manufacturing.Revenue.Fees = model.ProfitValue{
+		BasedOnBuyPrice:  manufacturing.Revenue.Total.BasedOnBuyPrice * fees.SellFee(model.PriceStrategyBuy),
+		BasedOnSellPrice: manufacturing.Revenue.Total.BasedOnSellPrice * fees.SellFee(model.PriceStrategySell),
+		BasedOnStrategy:  manufacturing.Revenue.Total.BasedOnStrategy * fees.SellFee(options.ProductStrategy),
 	}
 
 	// profit
 	manufacturing.Profit.Total = model.ProfitValue{
-		BasedOnBuyPrice:  manufacturing.Revenue.Total.BasedOnBuyPrice - manufacturing.Costs.Total,
-		BasedOnSellPrice: manufacturing.Revenue.Total.BasedOnSellPrice - manufacturing.Costs.Total,
+		BasedOnBuyPrice:  manufacturing.Revenue.Total.BasedOnBuyPrice - manufacturing.Revenue.Fees.BasedOnBuyPrice - manufacturing.Costs.Total,
+		BasedOnSellPrice: manufacturing.Revenue.Total.BasedOnSellPrice - manufacturing.Revenue.Fees.BasedOnSellPrice - manufacturing.Costs.Total,
+		BasedOnStrategy:  manufacturing.Revenue.Total.BasedOnStrategy - manufacturing.Revenue.Fees.BasedOnStrategy - manufacturing.Costs.Total,
 	}
 	manufacturing.Profit.PerItem = model.ProfitValue{
-		BasedOnBuyPrice:  manufacturing.Profit.Total.BasedOnBuyPrice / float64(manufacturing.Product.PortionSize) / float64(manufacturing.Runs),
-		BasedOnSellPrice: manufacturing.Profit.Total.BasedOnSellPrice / float64(manufacturing.Product.PortionSize) / float64(manufacturing.Runs),
+		BasedOnBuyPrice:  manufacturing.Profit.Total.BasedOnBuyPrice / units,
+		BasedOnSellPrice: manufacturing.Profit.Total.BasedOnSellPrice / units,
+		BasedOnStrategy:  manufacturing.Profit.Total.BasedOnStrategy / units,
 	}
 	manufacturing.Profit.PerDay = model.ProfitValue{
 		BasedOnBuyPrice:  manufacturing.Profit.PerItem.BasedOnBuyPrice * manufacturing.ItemsPerDay,
 		BasedOnSellPrice: manufacturing.Profit.PerItem.BasedOnSellPrice * manufacturing.ItemsPerDay,
+		BasedOnStrategy:  manufacturing.Profit.PerItem.BasedOnStrategy * manufacturing.ItemsPerDay,
 	}
 
 	// margin
 	manufacturing.Profit.Margin = model.ProfitValue{
-		BasedOnBuyPrice:  prices[productTypeID].Buy.Percentile/manufacturing.Costs.PerItem - 1,
-		BasedOnSellPrice: prices[productTypeID].Sell.Percentile/manufacturing.Costs.PerItem - 1,
+		BasedOnBuyPrice:  manufacturing.Profit.PerItem.BasedOnBuyPrice / manufacturing.Costs.PerItem,
+		BasedOnSellPrice: manufacturing.Profit.PerItem.BasedOnSellPrice / manufacturing.Costs.PerItem,
+		BasedOnStrategy:  manufacturing.Profit.PerItem.BasedOnStrategy / manufacturing.Costs.PerItem,
 	}
 
 	// other stats
 	manufacturing.BuyOrderVolume = prices[productTypeID].Buy.Volume
 	manufacturing.DailyBuyFactor = float64(manufacturing.BuyOrderVolume) / manufacturing.ItemsPerDay
 
+	// compare our output with the units that are actually traded
+	var demand *model.MarketDemand
+	if demand, err = db.GetMarketDemand(options.Hub.RegionID, productTypeID); err != nil {
+		return fmt.Errorf("could not retrieve market demand: %w", err)
+	}
+
+	// without any market history for the region, the demand is unknown rather than zero
+	if demand != nil && demand.AverageDailyVolume30 > 0 {
+		demand.DaysOfSupply = units / demand.AverageDailyVolume30
+		demand.DailyShare = manufacturing.ItemsPerDay / demand.AverageDailyVolume30
+	}
+
+	manufacturing.Demand = demand
+
 	return nil
 }
 
+// newManufacturingWithOptimalDecryptor calculates the manufacturing of a Tech II product with every available
+// decryptor (including none at all) and keeps the one that yields the highest profit per day, based on the
+// product strategy
+func newManufacturingWithOptimalDecryptor(builder SkillHolder, productTypeID int32, options *Options, manufacturing *model.Manufacturing) (err error) {
+	var (
+		best         *model.Manufacturing
+		bestProfits  map[string]model.ProfitValue
+		relicProfits = map[string]model.ProfitValue{}
+	)
+
+	// Tech 3 blueprints can be reverse engineered from different relics, which are compared as well
+	relics := []int32{options.RelicTypeID}
+	if options.RelicTypeID == 0 {
+		if relics, err = RelicTypeIDs(productTypeID); err != nil {
+			return err
+		}
+
+		if len(relics) == 0 {
+			relics = []int32{0}
+		}
+	}
+
+	candidates := append([]int32{NoDecryptor}, DecryptorTypeIDs()...)
+
+	for _, relicTypeID := range relics {
+		var relicBest *model.Manufacturing
+		profit := map[string]model.ProfitValue{}
+
+		for _, decryptorTypeID := range candidates {
+			m := model.Manufacturing{}
+
+			o := *options
+			o.OptimizeDecryptor = false
+			o.DecryptorTypeID = decryptorTypeID
+			o.RelicTypeID = relicTypeID
+
+			if err = NewManufacturing(builder, productTypeID, &o, &m); err != nil {
+				return err
+			}
+
+			// decryptors only make sense for invented products, so we can stop right here
+			if m.Invention.BlueprintType == nil {
+				*manufacturing = m
+				return nil
+			}
+
+			profit[strconv.Itoa(int(decryptorTypeID))] = m.Profit.PerDay
+
+			if relicBest == nil || m.Profit.PerDay.BasedOnStrategy > relicBest.Profit.PerDay.BasedOnStrategy {
+				relicBest = &m
+			}
+		}
+
+		if relicBest.IsTech3 {
+			relicProfits[strconv.Itoa(int(relicBest.Invention.BlueprintType.TypeID))] = relicBest.Profit.PerDay
+		}
+
+		if best == nil || relicBest.Profit.PerDay.BasedOnStrategy > best.Profit.PerDay.BasedOnStrategy {
+			best = relicBest
+			bestProfits = profit
+		}
+	}
+
+	*manufacturing = *best
+	manufacturing.DecryptorProfits = bestProfits
+
+	if manufacturing.IsTech3 {
+		manufacturing.RelicProfits = relicProfits
+	}
+
+	return nil
+}
+
+// EstimatedItemValue calculates the estimated item value (EIV) of one run of the specified blueprint
+// and activity. The EIV is always based on ME 0 and the adjusted prices supplied by ESI.
+func EstimatedItemValue(activityID model.IndustryActivityID, blueprint model.Blueprint) (eiv float64, err error) {
+	var materials []db.IndustryActivityMaterialResult
+	if materials, err = db.GetActivityMaterials(activityID, blueprint, 1, 1); err != nil {
+		return 0, err
+	}
+
+	for _, material := range materials {
+		var marketPrice *model.MarketPrice
+		if marketPrice, err = cache.GetMarketPrice(material.TypeID); err != nil {
+			return 0, err
+		}
+
+		eiv += float64(material.RawQuantity) * marketPrice.AdjustedPrice
+	}
+
+	return
+}
+
 func CalculateJobCost(eiv float64, systemCostIndex float64, iskBonus float64, facilityTax float64) (jobCost float64) {
 	// job cost according to the system cost index
 	jobCost = eiv * systemCostIndex
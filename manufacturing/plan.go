@@ -0,0 +1,376 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+// planCandidate is a product that a character could build, reduced to the numbers of a single run
+type planCandidate struct {
+	characterID     int32
+	activityID      model.IndustryActivityID
+	productTypeID   int32
+	productTypeName string
+	unitsPerRun     int
+	costPerRun      float64
+	profitPerRun    float64
+	timePerRun      float64
+	// costPerJob is paid once per job, regardless of its runs. It is the invention of the job's blueprint copy.
+	costPerJob float64
+	// maxRuns is the number of runs of a blueprint copy. It is 0 for originals, which have unlimited runs.
+	maxRuns int
+	// evaluate calculates the candidate again for a job with the specified runs. If it is nil, the numbers of a
+	// single run are scaled linearly.
+	evaluate func(runs int) (*planCandidate, error)
+}
+
+// planSlot identifies the slots of a builder for an activity. Manufacturing and reactions have separate slots.
+type planSlot struct {
+	characterID int32
+	activityID  model.IndustryActivityID
+}
+
+// PlanBuilder is a character that builds in a production plan, together with its available manufacturing and
+// reaction slots. If the slots are 0, all slots granted by the skills of the builder are used.
+type PlanBuilder struct {
+	CharacterID   int32
+	Builder       SkillHolder
+	Slots         int
+	ReactionSlots int
+}
+
+// NewProductionPlan allocates products and runs to the slots of the builders, so that the expected profit is
+// maximized within the time window, the budget and the demand limits of the request.
+//
+// This is a knapsack problem, so instead of trying all combinations, slots are filled greedily. Once ordered by
+// profit per slot time, which is optimal if slots are the limit, and once by profit per ISK, which is optimal if
+// the budget is the limit. The better of both plans is returned.
+//
+// Owned blueprints are shared by all builders. An original runs one job at a time and a copy is consumed by its
+// job, so they limit the number of parallel jobs of a product. Invented products need one invention per job.
+func NewProductionPlan(request *model.ProductionPlanRequest, builders []PlanBuilder, options *Options) (plan *model.ProductionPlan, err error) {
+	candidates := []planCandidate{}
+	demand := map[int32]int{}
+	blueprints := map[int32]int{}
+	window := request.Days * 24 * 3600
+
+	for _, builder := range builders {
+		for _, typeID := range request.TypeIDs {
+			var (
+				candidate *planCandidate
+				m         *model.Manufacturing
+			)
+
+			// products that cannot be built, e.g. because of a missing blueprint, are just not planned
+			if candidate, m, err = newPlanCandidate(builder, typeID, options, 0); err != nil {
+				log.Debugf("Not planning type %d: %v", typeID, err)
+				continue
+			}
+
+			if !m.HasBlueprint {
+				continue
+			}
+
+			// materials are rounded per job, which inflates the numbers of a single run, so candidates are
+			// compared by a job that fills the time window
+			if runs := candidate.runs(window); runs != m.Runs {
+				if candidate, m, err = newPlanCandidate(builder, typeID, options, runs); err != nil {
+					log.Debugf("Not planning type %d: %v", typeID, err)
+					continue
+				}
+			}
+
+			if m.Profit.Total.BasedOnStrategy <= 0 || m.Costs.Total <= 0 {
+				continue
+			}
+
+			candidate.evaluate = evaluateCandidate(builder, typeID, options)
+
+			candidates = append(candidates, *candidate)
+
+			demand[typeID] = demandLimit(request, m)
+
+			if blueprints[typeID], err = blueprintLimit(options, m); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	slots := map[planSlot]int{}
+	for _, builder := range builders {
+		slots[planSlot{builder.CharacterID, ActivityManufacturing}] = builder.Slots
+		slots[planSlot{builder.CharacterID, ActivityReaction}] = builder.ReactionSlots
+	}
+
+	for slot, n := range slots {
+		if n <= 0 {
+			slots[slot] = maxSlots(builderOf(builders, slot.characterID), slot.activityID)
+		}
+	}
+
+	// slots are the limit
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].profitPerRun/candidates[i].timePerRun > candidates[j].profitPerRun/candidates[j].timePerRun
+	})
+
+	plan = fillSlots(candidates, slots, demand, blueprints, request.Budget, window)
+
+	// the budget is the limit
+	if request.Budget > 0 {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].profitPerRun/candidates[i].costPerRun > candidates[j].profitPerRun/candidates[j].costPerRun
+		})
+
+		if other := fillSlots(candidates, slots, demand, blueprints, request.Budget, window); other.TotalProfit > plan.TotalProfit {
+			plan = other
+		}
+	}
+
+	return plan, nil
+}
+
+// newPlanCandidate calculates a candidate for a single job with the specified runs. If the runs are 0, the runs
+// of the options are used.
+func newPlanCandidate(builder PlanBuilder, typeID int32, options *Options, runs int) (candidate *planCandidate, m *model.Manufacturing, err error) {
+	jobOptions := *options
+	jobOptions.Jobs = 1
+
+	if runs > 0 {
+		jobOptions.Runs = runs
+	}
+
+	m = &model.Manufacturing{}
+	if err = NewManufacturing(builder.Builder, typeID, &jobOptions, m); err != nil {
+		return nil, nil, err
+	}
+
+	if m.Runs < 1 || m.Time <= 0 {
+		return nil, nil, fmt.Errorf("type %d has no build time", typeID)
+	}
+
+	var costPerJob float64
+	if m.Invention.BlueprintType != nil {
+		costPerJob = m.Invention.CostsForManufacturing
+	}
+
+	candidate = &planCandidate{
+		characterID:     builder.CharacterID,
+		activityID:      m.ActivityID,
+		productTypeID:   typeID,
+		productTypeName: m.Product.TypeName,
+		unitsPerRun:     m.UnitsPerRun,
+		costPerRun:      (m.Costs.Total - costPerJob) / float64(m.Runs),
+		profitPerRun:    (m.Profit.Total.BasedOnStrategy + costPerJob) / float64(m.Runs),
+		timePerRun:      float64(m.Time) / float64(m.Runs),
+		costPerJob:      costPerJob,
+	}
+
+	if m.IsTech2 || m.IsTech3 || (m.OwnedBlueprint != nil && m.OwnedBlueprint.IsCopy()) {
+		candidate.maxRuns = m.Runs
+	}
+
+	return candidate, m, nil
+}
+
+// evaluateCandidate returns a function that calculates the candidate for the runs of a job. Both plans usually
+// contain jobs with the same runs, so the results are kept.
+func evaluateCandidate(builder PlanBuilder, typeID int32, options *Options) func(runs int) (*planCandidate, error) {
+	evaluated := map[int]*planCandidate{}
+
+	return func(runs int) (candidate *planCandidate, err error) {
+		if candidate, ok := evaluated[runs]; ok {
+			return candidate, nil
+		}
+
+		if candidate, _, err = newPlanCandidate(builder, typeID, options, runs); err != nil {
+			return nil, err
+		}
+
+		evaluated[runs] = candidate
+
+		return candidate, nil
+	}
+}
+
+// builderOf returns the builder of a character
+func builderOf(builders []PlanBuilder, characterID int32) SkillHolder {
+	for _, builder := range builders {
+		if builder.CharacterID == characterID {
+			return builder.Builder
+		}
+	}
+
+	return nil
+}
+
+// runs returns the runs of a job that fills the time window, limited by the runs of a blueprint copy
+func (c *planCandidate) runs(window float64) int {
+	runs := int(window / c.timePerRun)
+
+	if c.maxRuns > 0 && runs > c.maxRuns {
+		runs = c.maxRuns
+	}
+
+	if runs < 1 {
+		runs = 1
+	}
+
+	return runs
+}
+
+// value returns the cost and profit of a job with the specified runs. Materials are rounded per job, so the
+// candidate is calculated again for the runs, if possible.
+func (c *planCandidate) value(runs int) (cost float64, profit float64) {
+	if c.evaluate != nil {
+		if exact, err := c.evaluate(runs); err != nil {
+			log.Debugf("Could not calculate %d runs of type %d, scaling a single run instead: %v", runs, c.productTypeID, err)
+		} else {
+			c = exact
+		}
+	}
+
+	return c.costPerRun*float64(runs) + c.costPerJob, c.profitPerRun*float64(runs) - c.costPerJob
+}
+
+// demandLimit returns the maximum number of units of a product that should be built. It returns -1, if the units
+// are not limited. The market share cannot limit products whose demand is unknown.
+func demandLimit(request *model.ProductionPlanRequest, m *model.Manufacturing) int {
+	if limit, ok := request.DemandLimits[strconv.Itoa(int(m.Product.TypeID))]; ok {
+		return limit
+	}
+
+	if request.MaxMarketShare > 0 && m.Demand != nil {
+		return int(m.Demand.AverageDailyVolume30 * request.Days * request.MaxMarketShare)
+	}
+
+	return -1
+}
+
+// blueprintLimit returns the maximum number of parallel jobs of a product, which is the number of owned blueprints
+// of the corporation. It returns -1, if the jobs are not limited, i.e. if no corporation is specified or the
+// blueprint of each job is invented.
+func blueprintLimit(options *Options, m *model.Manufacturing) (int, error) {
+	if options.CorporationID == 0 || m.OwnedBlueprint == nil {
+		return -1, nil
+	}
+
+	count, err := db.CountCorporationBlueprints(options.CorporationID, m.OwnedBlueprint.TypeID)
+	if err != nil {
+		return 0, fmt.Errorf("could not count owned blueprints: %w", err)
+	}
+
+	return count, nil
+}
+
+// fillSlots greedily assigns the candidates in their order to the free slots. The demand and blueprint limits are
+// shared by all builders and -1 means unlimited.
+func fillSlots(candidates []planCandidate, slots map[planSlot]int, demand map[int32]int, blueprints map[int32]int, budget float64, window float64) (plan *model.ProductionPlan) {
+	plan = &model.ProductionPlan{
+		Jobs: []model.PlannedJob{},
+	}
+
+	free := map[planSlot]int{}
+	for slot, n := range slots {
+		free[slot] = n
+	}
+
+	remaining := map[int32]int{}
+	for typeID, n := range demand {
+		remaining[typeID] = n
+	}
+
+	jobs := map[int32]int{}
+	for typeID, n := range blueprints {
+		jobs[typeID] = n
+	}
+
+	for i := range candidates {
+		candidate := &candidates[i]
+		slot := planSlot{candidate.characterID, candidate.activityID}
+
+		for free[slot] > 0 && jobs[candidate.productTypeID] != 0 {
+			// as many runs as fit into the time window
+			runs := int(window / candidate.timePerRun)
+
+			if candidate.maxRuns > 0 && runs > candidate.maxRuns {
+				runs = candidate.maxRuns
+			}
+
+			if limit := remaining[candidate.productTypeID]; limit >= 0 && runs*candidate.unitsPerRun > limit {
+				runs = limit / candidate.unitsPerRun
+			}
+
+			if budget > 0 {
+				if affordable := int((budget - plan.TotalCost - candidate.costPerJob) / candidate.costPerRun); runs > affordable {
+					runs = affordable
+				}
+			}
+
+			if runs < 1 {
+				break
+			}
+
+			cost, profit := candidate.value(runs)
+
+			// a short job might not make up for the invention of its blueprint copy
+			if profit <= 0 || (budget > 0 && plan.TotalCost+cost > budget) {
+				break
+			}
+
+			job := model.PlannedJob{
+				CharacterID:     candidate.characterID,
+				ActivityID:      candidate.activityID,
+				ProductTypeID:   candidate.productTypeID,
+				ProductTypeName: candidate.productTypeName,
+				Runs:            runs,
+				Units:           runs * candidate.unitsPerRun,
+				Duration:        int(math.Ceil(candidate.timePerRun * float64(runs))),
+				Cost:            cost,
+				Profit:          profit,
+			}
+
+			plan.Jobs = append(plan.Jobs, job)
+			plan.TotalCost += job.Cost
+			plan.TotalProfit += job.Profit
+
+			free[slot]--
+
+			if jobs[candidate.productTypeID] > 0 {
+				jobs[candidate.productTypeID]--
+			}
+
+			if remaining[candidate.productTypeID] >= 0 {
+				remaining[candidate.productTypeID] -= job.Units
+			}
+		}
+	}
+
+	for _, n := range free {
+		plan.FreeSlots += n
+	}
+
+	plan.UsedSlots = len(plan.Jobs)
+
+	return plan
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oxisto/titan/model"
+)
+
+func TestDemandLimit(t *testing.T) {
+	product := &model.Type{TypeID: 34}
+
+	type args struct {
+		request *model.ProductionPlanRequest
+		m       *model.Manufacturing
+	}
+	tests := []struct {
+		name string
+		args args
+		want int
+	}{
+		{
+			name: "unlimited",
+			args: args{
+				request: &model.ProductionPlanRequest{Days: 10},
+				m:       &model.Manufacturing{Product: product, Demand: &model.MarketDemand{AverageDailyVolume30: 50}},
+			},
+			want: -1,
+		},
+		{
+			name: "explicit limit",
+			args: args{
+				request: &model.ProductionPlanRequest{Days: 10, DemandLimits: map[string]int{"34": 100}},
+				m:       &model.Manufacturing{Product: product},
+			},
+			want: 100,
+		},
+		{
+			name: "explicit limit before market share",
+			args: args{
+				request: &model.ProductionPlanRequest{Days: 10, MaxMarketShare: 0.1, DemandLimits: map[string]int{"34": 100}},
+				m:       &model.Manufacturing{Product: product, Demand: &model.MarketDemand{AverageDailyVolume30: 50}},
+			},
+			want: 100,
+		},
+		{
+			name: "market share",
+			args: args{
+				request: &model.ProductionPlanRequest{Days: 10, MaxMarketShare: 0.1},
+				m:       &model.Manufacturing{Product: product, Demand: &model.MarketDemand{AverageDailyVolume30: 50}},
+			},
+			want: 50,
+		},
+		{
+			name: "market share without traded volume",
+			args: args{
+				request: &model.ProductionPlanRequest{Days: 10, MaxMarketShare: 0.1},
+				m:       &model.Manufacturing{Product: product, Demand: &model.MarketDemand{}},
+			},
+			want: 0,
+		},
+		{
+			name: "market share with unknown demand",
+			args: args{
+				request: &model.ProductionPlanRequest{Days: 10, MaxMarketShare: 0.1},
+				m:       &model.Manufacturing{Product: product},
+			},
+			want: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := demandLimit(tt.args.request, tt.args.m); got != tt.want {
+				t.Errorf("demandLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillSlots(t *testing.T) {
+	const (
+		typeID = 34
+		day    = 24 * 3600
+	)
+
+	// a run takes an hour, so 24 runs fit into a day
+	candidate := planCandidate{
+		characterID:   1,
+		activityID:    ActivityManufacturing,
+		productTypeID: typeID,
+		unitsPerRun:   1,
+		costPerRun:    100,
+		profitPerRun:  100,
+		timePerRun:    3600,
+	}
+
+	withCharacter := func(c planCandidate, characterID int32) planCandidate {
+		c.characterID = characterID
+		return c
+	}
+
+	type args struct {
+		candidates []planCandidate
+		slots      map[planSlot]int
+		demand     map[int32]int
+		blueprints map[int32]int
+		budget     float64
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantRuns      []int
+		wantFreeSlots int
+		wantProfit    float64
+	}{
+		{
+			name: "unlimited",
+			args: args{
+				candidates: []planCandidate{candidate, withCharacter(candidate, 2)},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 2, {2, ActivityManufacturing}: 1},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: -1},
+			},
+			wantRuns:   []int{24, 24, 24},
+			wantProfit: 7200,
+		},
+		{
+			name: "owned blueprints are shared by all builders",
+			args: args{
+				candidates: []planCandidate{candidate, withCharacter(candidate, 2)},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 1, {2, ActivityManufacturing}: 2},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: 2},
+			},
+			wantRuns:      []int{24, 24},
+			wantFreeSlots: 1,
+			wantProfit:    4800,
+		},
+		{
+			name: "no owned blueprint",
+			args: args{
+				candidates: []planCandidate{candidate},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 2},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: 0},
+			},
+			wantRuns:      []int{},
+			wantFreeSlots: 2,
+		},
+		{
+			name: "runs of a copy",
+			args: args{
+				candidates: []planCandidate{func() planCandidate { c := candidate; c.maxRuns = 5; return c }()},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 2},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: 1},
+			},
+			wantRuns:      []int{5},
+			wantFreeSlots: 1,
+			wantProfit:    500,
+		},
+		{
+			name: "demand is shared by all builders",
+			args: args{
+				candidates: []planCandidate{candidate, withCharacter(candidate, 2)},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 1, {2, ActivityManufacturing}: 2},
+				demand:     map[int32]int{typeID: 30},
+				blueprints: map[int32]int{typeID: -1},
+			},
+			wantRuns:      []int{24, 6},
+			wantFreeSlots: 1,
+			wantProfit:    3000,
+		},
+		{
+			name: "one invention per job",
+			args: args{
+				candidates: []planCandidate{func() planCandidate { c := candidate; c.costPerJob = 1000; return c }()},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 3},
+				demand:     map[int32]int{typeID: 30},
+				blueprints: map[int32]int{typeID: -1},
+			},
+			// the second job of 6 runs does not make up for its invention
+			wantRuns:      []int{24},
+			wantFreeSlots: 2,
+			wantProfit:    1400,
+		},
+		{
+			name: "reactions have their own slots",
+			args: args{
+				candidates: []planCandidate{func() planCandidate { c := candidate; c.activityID = ActivityReaction; return c }(), candidate},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 1, {1, ActivityReaction}: 1},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: -1},
+			},
+			wantRuns:   []int{24, 24},
+			wantProfit: 4800,
+		},
+		{
+			name: "evaluated for the runs of the job",
+			args: args{
+				candidates: []planCandidate{func() planCandidate {
+					c := candidate
+					c.evaluate = func(runs int) (*planCandidate, error) {
+						exact := candidate
+						exact.profitPerRun = 50
+						return &exact, nil
+					}
+					return c
+				}()},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 1},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: -1},
+			},
+			wantRuns:   []int{24},
+			wantProfit: 1200,
+		},
+		{
+			name: "budget",
+			args: args{
+				candidates: []planCandidate{candidate},
+				slots:      map[planSlot]int{{1, ActivityManufacturing}: 2},
+				demand:     map[int32]int{typeID: -1},
+				blueprints: map[int32]int{typeID: -1},
+				budget:     1000,
+			},
+			wantRuns:      []int{10},
+			wantFreeSlots: 1,
+			wantProfit:    1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := fillSlots(tt.args.candidates, tt.args.slots, tt.args.demand, tt.args.blueprints, tt.args.budget, day)
+
+			gotRuns := []int{}
+			for _, job := range plan.Jobs {
+				gotRuns = append(gotRuns, job.Runs)
+			}
+
+			if !reflect.DeepEqual(gotRuns, tt.wantRuns) {
+				t.Errorf("fillSlots() runs = %v, want %v", gotRuns, tt.wantRuns)
+			}
+			if plan.FreeSlots != tt.wantFreeSlots {
+				t.Errorf("fillSlots() FreeSlots = %v, want %v", plan.FreeSlots, tt.wantFreeSlots)
+			}
+			if plan.UsedSlots != len(tt.wantRuns) {
+				t.Errorf("fillSlots() UsedSlots = %v, want %v", plan.UsedSlots, len(tt.wantRuns))
+			}
+			if plan.TotalProfit != tt.wantProfit {
+				t.Errorf("fillSlots() TotalProfit = %v, want %v", plan.TotalProfit, tt.wantProfit)
+			}
+		})
+	}
+}
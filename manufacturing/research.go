@@ -0,0 +1,244 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/model"
+)
+
+const (
+	// ResearchJobCostFactor is the share of the product's estimated item value that is used as base for research job costs
+	ResearchJobCostFactor = 0.02
+
+	// CopyingJobCostFactor is the share of the product's estimated item value that is used as base for copying job costs
+	CopyingJobCostFactor = 0.02
+)
+
+// researchLevelModifiers contains the duration of each research level, relative to the duration of level 1
+var researchLevelModifiers = []float64{0, 105, 250, 595, 1414, 3360, 8000, 19000, 45255, 107700, 256000}
+
+// researchLevelModifier returns the time and job cost multiplier of a research level
+func researchLevelModifier(level int) float64 {
+	return researchLevelModifiers[level] / researchLevelModifiers[1]
+}
+
+// NewResearch calculates the time and job costs to research the blueprint of a product from options.ME and
+// options.TE to options.TargetME and options.TargetTE. If options.Copies is set, the costs of copying the
+// researched blueprint are calculated as well.
+//
+// The material savings per run are compared against the job costs of the material efficiency research to get
+// the number of runs after which the research pays back. BreakEvenRuns is 0 if no material efficiency research
+// is necessary and -1 if it never pays back.
+func NewResearch(builder SkillHolder, productTypeID int32, options *Options) (research *model.Research, err error) {
+	if options == nil {
+		options = NewOptions()
+	}
+
+	if options.ME < 0 || options.TargetME > 10 || options.ME > options.TargetME {
+		return nil, fmt.Errorf("invalid material efficiency range %d to %d", options.ME, options.TargetME)
+	}
+
+	if options.TE < 0 || options.TargetTE > 20 || options.TE > options.TargetTE || options.TE%2 != 0 || options.TargetTE%2 != 0 {
+		return nil, fmt.Errorf("invalid time efficiency range %d to %d", options.TE, options.TargetTE)
+	}
+
+	var product *model.Type
+	if product, err = db.GetType(productTypeID); err != nil {
+		return nil, fmt.Errorf("could not retrieve type: %w", err)
+	}
+
+	if product.IsTechII() || product.IsTechIII() {
+		return nil, errors.New("invented blueprints cannot be researched")
+	}
+
+	blueprint := db.GetBlueprint(ActivityManufacturing, productTypeID).Blueprint
+	if blueprint.TypeID == 0 {
+		return nil, errors.New("item cannot be manufactured")
+	}
+
+	research = &model.Research{
+		ProductTypeID: productTypeID,
+		CurrentME:     options.ME,
+		TargetME:      options.TargetME,
+		CurrentTE:     options.TE,
+		TargetTE:      options.TargetTE,
+	}
+
+	if research.BlueprintType, err = db.GetType(blueprint.TypeID); err != nil {
+		return nil, fmt.Errorf("could not retrieve type: %w", err)
+	}
+
+	var (
+		eiv   float64
+		index *model.SystemCostIndex
+	)
+
+	facility := options.facility()
+
+	// all research job costs are based on the estimated item value of the product
+	if eiv, err = EstimatedItemValue(ActivityManufacturing, blueprint); err != nil {
+		return nil, err
+	}
+
+	if index, err = cache.GetSystemCostIndex(facility.SolarSystemID); err != nil {
+		return nil, fmt.Errorf("could not retrieve system cost index: %w", err)
+	}
+
+	// material efficiency advances one level per percent, time efficiency one level per two percent
+	if research.MaterialEfficiency, err = newResearchLevels(builder, blueprint, product, ActivityResearchME, options.ME, options.TargetME, 1, eiv, index, facility); err != nil {
+		return nil, err
+	}
+
+	if research.TimeEfficiency, err = newResearchLevels(builder, blueprint, product, ActivityResearchTE, options.TE, options.TargetTE, 2, eiv, index, facility); err != nil {
+		return nil, err
+	}
+
+	meJobCost := 0.0
+	for _, level := range research.MaterialEfficiency {
+		meJobCost += level.JobCost
+		research.TotalJobCost += level.JobCost
+		research.TotalTime += level.Time
+	}
+
+	for _, level := range research.TimeEfficiency {
+		research.TotalJobCost += level.JobCost
+		research.TotalTime += level.Time
+	}
+
+	if options.Copies > 0 {
+		if research.Copying, err = newCopying(builder, blueprint, product, options, eiv, index, facility); err != nil {
+			return nil, err
+		}
+	}
+
+	// compare the manufacturing with the current and the target efficiency
+	current := model.Manufacturing{}
+	target := model.Manufacturing{}
+
+	// the efficiencies are compared explicitly, so owned blueprints must not override them
+	o := *options
+	o.CorporationID = 0
+	if err = NewManufacturing(builder, productTypeID, &o, &current); err != nil {
+		return nil, err
+	}
+
+	o.ME = options.TargetME
+	o.TE = options.TargetTE
+	if err = NewManufacturing(builder, productTypeID, &o, &target); err != nil {
+		return nil, err
+	}
+
+	research.MaterialCostPerRun = current.Costs.TotalMaterials / float64(current.Runs)
+	research.TargetMaterialCostPerRun = target.Costs.TotalMaterials / float64(target.Runs)
+	research.SavingsPerRun = research.MaterialCostPerRun - research.TargetMaterialCostPerRun
+	research.TimeSavedPerRun = (current.Time - target.Time) / current.Runs
+
+	switch {
+	case meJobCost == 0:
+		research.BreakEvenRuns = 0
+	case research.SavingsPerRun <= 0:
+		research.BreakEvenRuns = -1
+	default:
+		research.BreakEvenRuns = int(math.Ceil(meJobCost / research.SavingsPerRun))
+	}
+
+	return research, nil
+}
+
+// newResearchLevels calculates time and job cost of every research level between the current and the target value
+func newResearchLevels(builder SkillHolder, blueprint model.Blueprint, product *model.Type, activityID model.IndustryActivityID, current int64, target int64, step int64, eiv float64, index *model.SystemCostIndex, facility *model.Facility) (levels []model.ResearchLevel, err error) {
+	levels = []model.ResearchLevel{}
+
+	if current == target {
+		return levels, nil
+	}
+
+	var facilityModifiers *FacilityModifiers
+	if facilityModifiers, err = NewFacilityModifiers(facility, activityID, product); err != nil {
+		return nil, err
+	}
+
+	timeModifiers := map[string]float64{"Skills": skillTimeModifier(builder, activityID)}
+	for key, mod := range facilityModifiers.Time {
+		timeModifiers[key] = mod
+	}
+
+	var activity db.IndustryActivityResult
+	if activity, err = db.GetIndustryActivity(blueprint.TypeID, activityID); err != nil {
+		return nil, err
+	}
+
+	timeModifier := CalculateModifier(timeModifiers)
+
+	for value := current + step; value <= target; value += step {
+		level := int(value / step)
+		levelModifier := researchLevelModifier(level)
+
+		levels = append(levels, model.ResearchLevel{
+			Level:   level,
+			Value:   value,
+			Time:    int(math.Ceil(float64(activity.Time) * levelModifier * timeModifier)),
+			JobCost: CalculateJobCost(eiv*ResearchJobCostFactor*levelModifier, index.CostIndex(activityID), facilityModifiers.Cost, facility.Tax),
+		})
+	}
+
+	return levels, nil
+}
+
+// newCopying calculates time and job cost of copying a blueprint
+func newCopying(builder SkillHolder, blueprint model.Blueprint, product *model.Type, options *Options, eiv float64, index *model.SystemCostIndex, facility *model.Facility) (copying *model.Copying, err error) {
+	copying = &model.Copying{
+		Copies: options.Copies,
+		Runs:   options.CopyRuns,
+	}
+
+	if copying.Runs < 1 {
+		copying.Runs = 1
+	}
+
+	if copying.Runs > blueprint.MaxProductionLimit {
+		copying.Runs = blueprint.MaxProductionLimit
+	}
+
+	var facilityModifiers *FacilityModifiers
+	if facilityModifiers, err = NewFacilityModifiers(facility, ActivityCopying, product); err != nil {
+		return nil, err
+	}
+
+	timeModifiers := map[string]float64{"Skills": skillTimeModifier(builder, ActivityCopying)}
+	for key, mod := range facilityModifiers.Time {
+		timeModifiers[key] = mod
+	}
+
+	var activity db.IndustryActivityResult
+	if activity, err = db.GetIndustryActivity(blueprint.TypeID, ActivityCopying); err != nil {
+		return nil, err
+	}
+
+	totalRuns := float64(copying.Runs * copying.Copies)
+
+	copying.Time = int(math.Ceil(float64(activity.Time) * totalRuns * CalculateModifier(timeModifiers)))
+	copying.JobCost = CalculateJobCost(eiv*CopyingJobCostFactor*totalRuns, index.CostIndex(ActivityCopying), facilityModifiers.Cost, facility.Tax)
+
+	return copying, nil
+}
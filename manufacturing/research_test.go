@@ -0,0 +1,49 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package manufacturing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResearchLevelModifier(t *testing.T) {
+	// the multipliers of the research time and job cost of each level, as shown in game
+	tests := []struct {
+		name  string
+		level int
+		want  float64
+	}{
+		{"level 1", 1, 1},
+		{"level 2", 2, 2.38},
+		{"level 3", 3, 5.67},
+		{"level 4", 4, 13.5},
+		{"level 5", 5, 32},
+		{"level 6", 6, 76},
+		{"level 7", 7, 181},
+		{"level 8", 8, 431},
+		{"level 9", 9, 1025},
+		{"level 10", 10, 2436},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := researchLevelModifier(tt.level); math.Abs(got-tt.want)/tt.want > 0.005 {
+				t.Errorf("researchLevelModifier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
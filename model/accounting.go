@@ -0,0 +1,260 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+// RealizedProfit is the profit of delivered industry jobs, based on the actual prices materials were bought and
+// products were sold for
+type RealizedProfit struct {
+	CorporationID int32           `json:"corporationID"`
+	Jobs          []JobProfit     `json:"jobs"`
+	Products      []ProductProfit `json:"products"`
+}
+
+// JobProfit is the realized profit of a single industry job
+type JobProfit struct {
+	JobID         int32   `json:"jobID"`
+	ProductTypeID int32   `json:"productTypeID"`
+	Units         int     `json:"units"`
+	MaterialCost  float64 `json:"materialCost"`
+	JobCost       float64 `json:"jobCost"`
+	CostPerUnit   float64 `json:"costPerUnit"`
+	// MaterialEfficiencyKnown is false, if the blueprint of the job is not owned anymore, e.g. because it was a
+	// copy that was consumed. Its materials are then calculated without material efficiency, which overstates
+	// the material cost.
+	MaterialEfficiencyKnown bool `json:"materialEfficiencyKnown"`
+	// Facility is the configured facility whose bonuses are applied to the materials of the job. It is empty, if
+	// the job was installed in a structure that does not match any configured facility.
+	Facility string `json:"facility"`
+	// UncoveredMaterials is the quantity of materials that could not be matched to a purchase, e.g. because they
+	// were mined or bought before the oldest known transaction. They are not part of the material cost.
+	UncoveredMaterials int     `json:"uncoveredMaterials"`
+	SoldUnits          int     `json:"soldUnits"`
+	Revenue            float64 `json:"revenue"`
+	RealizedProfit     float64 `json:"realizedProfit"`
+}
+
+// ProductProfit is the realized profit of all jobs of a product, compared to the profit that is predicted for it
+type ProductProfit struct {
+	ProductTypeID   int32  `json:"productTypeID"`
+	ProductTypeName string `json:"productTypeName"`
+	Jobs            int    `json:"jobs"`
+	// JobsWithUnknownMaterialEfficiency is the number of jobs, whose material cost is overstated, because the
+	// material efficiency of their blueprint is not known
+	JobsWithUnknownMaterialEfficiency int     `json:"jobsWithUnknownMaterialEfficiency"`
+	Units                             int     `json:"units"`
+	SoldUnits                         int     `json:"soldUnits"`
+	Revenue                           float64 `json:"revenue"`
+	CostOfSoldUnits                   float64 `json:"costOfSoldUnits"`
+	RealizedProfit                    float64 `json:"realizedProfit"`
+	RealizedProfitPerItem             float64 `json:"realizedProfitPerItem"`
+	PredictedProfitPerItem            float64 `json:"predictedProfitPerItem"`
+}
+
+// CostBasisMethod specifies how the cost of items is determined, if they were acquired at different prices
+type CostBasisMethod string
+
+const (
+	// CostBasisFIFO uses the cost of the oldest items first
+	CostBasisFIFO = CostBasisMethod("fifo")
+	// CostBasisWeightedAverage uses the average cost of all items that are held
+	CostBasisWeightedAverage = CostBasisMethod("average")
+)
+
+// IsValid returns true, if the cost basis method is known
+func (m CostBasisMethod) IsValid() bool {
+	return m == CostBasisFIFO || m == CostBasisWeightedAverage
+}
+
+// Holdings are the items a corporation holds according to its transactions and industry jobs, together with
+// their cost basis and their current market value
+type Holdings struct {
+	CorporationID  int32           `json:"corporationID"`
+	Method         CostBasisMethod `json:"method"`
+	Hub            TradeHub        `json:"hub"`
+	Strategy       PriceStrategy   `json:"strategy"`
+	Types          []TypeHolding   `json:"types"`
+	CostBasis      float64         `json:"costBasis"`
+	MarketValue    float64         `json:"marketValue"`
+	UnrealizedGain float64         `json:"unrealizedGain"`
+}
+
+// TypeHolding are the held items of a single type
+type TypeHolding struct {
+	TypeID             int32             `json:"typeID"`
+	TypeName           string            `json:"typeName"`
+	Quantity           int               `json:"quantity"`
+	CostBasis          float64           `json:"costBasis"`
+	AverageCost        float64           `json:"averageCost"`
+	MarketPricePerUnit float64           `json:"marketPricePerUnit"`
+	MarketValue        float64           `json:"marketValue"`
+	UnrealizedGain     float64           `json:"unrealizedGain"`
+	Locations          []LocationHolding `json:"locations"`
+}
+
+// LocationHolding are the held items of a single type that entered the corporation at a location
+type LocationHolding struct {
+	LocationID int64   `json:"locationID"`
+	Quantity   int     `json:"quantity"`
+	CostBasis  float64 `json:"costBasis"`
+}
+
+// ReportPeriod is the time span that journal entries are grouped by in reports
+type ReportPeriod string
+
+const (
+	ReportPeriodDay   = ReportPeriod("day")
+	ReportPeriodWeek  = ReportPeriod("week")
+	ReportPeriodMonth = ReportPeriod("month")
+)
+
+// IsValid returns true, if the report period is known
+func (p ReportPeriod) IsValid() bool {
+	return p == ReportPeriodDay || p == ReportPeriodWeek || p == ReportPeriodMonth
+}
+
+// ReportCategory is an income or expense category of the profit and loss report
+type ReportCategory string
+
+const (
+	ReportCategoryMarket         = ReportCategory("market")
+	ReportCategoryBrokerFees     = ReportCategory("brokerFees")
+	ReportCategoryTransactionTax = ReportCategory("transactionTax")
+	ReportCategoryIndustry       = ReportCategory("industry")
+	ReportCategoryBounty         = ReportCategory("bounty")
+	ReportCategoryContracts      = ReportCategory("contracts")
+	ReportCategoryDonations      = ReportCategory("donations")
+	ReportCategoryTransfers      = ReportCategory("transfers")
+	ReportCategoryOther          = ReportCategory("other")
+)
+
+// ReportCategories maps the ref types of journal entries to the categories of the profit and loss report.
+// Ref types that are not listed here belong to ReportCategoryOther.
+var ReportCategories = map[string]ReportCategory{
+	"market_transaction":                ReportCategoryMarket,
+	"market_escrow":                     ReportCategoryMarket,
+	"brokers_fee":                       ReportCategoryBrokerFees,
+	"transaction_tax":                   ReportCategoryTransactionTax,
+	"industry_job_tax":                  ReportCategoryIndustry,
+	"manufacturing":                     ReportCategoryIndustry,
+	"reaction":                          ReportCategoryIndustry,
+	"copying":                           ReportCategoryIndustry,
+	"researching_material_productivity": ReportCategoryIndustry,
+	"researching_time_productivity":     ReportCategoryIndustry,
+	"researching_technology":            ReportCategoryIndustry,
+	"reverse_engineering":               ReportCategoryIndustry,
+	"bounty_prize":                      ReportCategoryBounty,
+	"bounty_prizes":                     ReportCategoryBounty,
+	"ess_escrow_transfer":               ReportCategoryBounty,
+	"contract_price":                    ReportCategoryContracts,
+	"contract_price_payment_corp":       ReportCategoryContracts,
+	"contract_reward":                   ReportCategoryContracts,
+	"contract_reward_deposited":         ReportCategoryContracts,
+	"contract_reward_deposited_corp":    ReportCategoryContracts,
+	"contract_reward_refund":            ReportCategoryContracts,
+	"contract_collateral":               ReportCategoryContracts,
+	"contract_collateral_payout":        ReportCategoryContracts,
+	"contract_collateral_refund":        ReportCategoryContracts,
+	"contract_deposit":                  ReportCategoryContracts,
+	"contract_deposit_refund":           ReportCategoryContracts,
+	"contract_brokers_fee":              ReportCategoryContracts,
+	"contract_brokers_fee_corp":         ReportCategoryContracts,
+	"contract_sales_tax":                ReportCategoryContracts,
+	"player_donation":                   ReportCategoryDonations,
+	"corporation_account_withdrawal":    ReportCategoryTransfers,
+}
+
+// NewReportCategory returns the report category of a journal ref type
+func NewReportCategory(refType string) ReportCategory {
+	if category, ok := ReportCategories[refType]; ok {
+		return category
+	}
+
+	return ReportCategoryOther
+}
+
+// ProfitAndLoss is the income and expenses of a corporation, grouped by period, wallet division and category
+type ProfitAndLoss struct {
+	CorporationID int32              `json:"corporationID"`
+	Period        ReportPeriod       `json:"period"`
+	From          time.Time          `json:"from"`
+	To            time.Time          `json:"to"`
+	Rows          []ProfitAndLossRow `json:"rows"`
+	Income        float64            `json:"income"`
+	Expenses      float64            `json:"expenses"`
+	Net           float64            `json:"net"`
+}
+
+// ProfitAndLossRow is the income and expenses of a single category in a wallet division during a period.
+// Expenses are negative.
+type ProfitAndLossRow struct {
+	Start    time.Time      `json:"start"`
+	Division int32          `json:"division"`
+	Category ReportCategory `json:"category"`
+	Income   float64        `json:"income"`
+	Expenses float64        `json:"expenses"`
+	Net      float64        `json:"net"`
+	Entries  int            `json:"entries"`
+}
+
+// CashFlowForecastRequest specifies the time span of a cash flow forecast and the expenses that are planned
+// during it, such as the materials of the next build batch
+type CashFlowForecastRequest struct {
+	Days     int                `json:"days"`
+	Expenses []ScheduledExpense `json:"expenses"`
+}
+
+// ScheduledExpense is an expense that is planned at a certain date. The amount is positive.
+type ScheduledExpense struct {
+	Date        time.Time `json:"date"`
+	Amount      float64   `json:"amount"`
+	Description string    `json:"description"`
+}
+
+// CashFlowForecast projects the wallet balance of a corporation, based on the current balance, the value of the
+// products of running industry jobs and the scheduled expenses
+type CashFlowForecast struct {
+	CorporationID     int32              `json:"corporationID"`
+	Hub               TradeHub           `json:"hub"`
+	Strategy          PriceStrategy      `json:"strategy"`
+	StartBalance      float64            `json:"startBalance"`
+	EndBalance        float64            `json:"endBalance"`
+	LowestBalance     float64            `json:"lowestBalance"`
+	LowestBalanceDate time.Time          `json:"lowestBalanceDate"`
+	Days              []CashFlowDay      `json:"days"`
+	Jobs              []ForecastJob      `json:"jobs"`
+	Expenses          []ScheduledExpense `json:"expenses"`
+}
+
+// CashFlowDay is the projected income, expenses and closing balance of a single day
+type CashFlowDay struct {
+	Date     time.Time `json:"date"`
+	Income   float64   `json:"income"`
+	Expenses float64   `json:"expenses"`
+	Balance  float64   `json:"balance"`
+}
+
+// ForecastJob is a running industry job and the expected value of its products
+type ForecastJob struct {
+	JobID           int32     `json:"jobID"`
+	ProductTypeID   int32     `json:"productTypeID"`
+	ProductTypeName string    `json:"productTypeName"`
+	Units           int       `json:"units"`
+	Value           float64   `json:"value"`
+	EndDate         time.Time `json:"endDate"`
+}
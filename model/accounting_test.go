@@ -0,0 +1,48 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+)
+
+func TestNewReportCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		refType string
+		want    ReportCategory
+	}{
+		{"market transaction", "market_transaction", ReportCategoryMarket},
+		{"broker fee", "brokers_fee", ReportCategoryBrokerFees},
+		{"transaction tax", "transaction_tax", ReportCategoryTransactionTax},
+		{"manufacturing", "manufacturing", ReportCategoryIndustry},
+		{"industry job tax", "industry_job_tax", ReportCategoryIndustry},
+		{"bounty", "bounty_prizes", ReportCategoryBounty},
+		{"contract", "contract_price", ReportCategoryContracts},
+		{"donation", "player_donation", ReportCategoryDonations},
+		{"withdrawal", "corporation_account_withdrawal", ReportCategoryTransfers},
+		{"unknown ref type", "planetary_import_tax", ReportCategoryOther},
+		{"empty", "", ReportCategoryOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewReportCategory(tt.refType); got != tt.want {
+				t.Errorf("NewReportCategory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
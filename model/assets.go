@@ -17,39 +17,107 @@ limitations under the License.
 package model
 
 import (
-	"fmt"
-	"time"
+	"strconv"
+	"strings"
 )
 
+// LocationFlagCorporationHangar is the prefix of the location flags of the seven corporation hangar divisions
+const LocationFlagCorporationHangar = "CorpSAG"
+
 type CorporationAssets struct {
-	expireDate *time.Time
+	CorporationID int32                 `json:"corporationID"`
+	Assets        []*AssetWithTypeNames `json:"assets"`
+}
 
-	CorporationID int32
-	Assets        map[string]Asset
+// InventoryValuation specifies how materials on hand are valued
+type InventoryValuation string
+
+const (
+	// InventoryValuationMarket values materials on hand at the current market price, i.e. at what we could sell them for
+	InventoryValuationMarket = InventoryValuation("market")
+	// InventoryValuationAcquisition values materials on hand at the average price we paid for them
+	InventoryValuationAcquisition = InventoryValuation("acquisition")
+)
+
+// IsValid returns true, if the inventory valuation is known
+func (v InventoryValuation) IsValid() bool {
+	return v == InventoryValuationMarket || v == InventoryValuationAcquisition
 }
 
+// Asset is an item owned by a corporation. Its location is either a station, a structure, a solar system or
+// another item, such as a container or an office. The location an asset is ultimately stored in, as well
+// as its hangar division, is resolved with ResolveAssetLocations.
 type Asset struct {
-	IsSingleton  bool
-	ItemID       int64
-	LocationFlag string
-	LocationID   int64
-	LocationType string
-	Quantity     int
-	TypeID       int32
+	ItemID         int64  `json:"itemID" db:"itemID"`
+	CorporationID  int32  `json:"corporationID" db:"corporationID"`
+	TypeID         int32  `json:"typeID" db:"typeID"`
+	Quantity       int32  `json:"quantity" db:"quantity"`
+	IsSingleton    bool   `json:"isSingleton" db:"isSingleton"`
+	LocationID     int64  `json:"locationID" db:"locationID"`
+	LocationFlag   string `json:"locationFlag" db:"locationFlag"`
+	LocationType   string `json:"locationType" db:"locationType"`
+	RootLocationID int64  `json:"rootLocationID" db:"rootLocationID"`
+	Division       int32  `json:"division" db:"division"`
 }
 
-func (c *CorporationAssets) ID() int32 {
-	return c.CorporationID
+type AssetWithTypeNames struct {
+	*Asset
+	TypeName         string  `json:"typeName" db:"typeName"`
+	RootLocationName *string `json:"rootLocationName" db:"rootLocationName"`
 }
 
-func (c *CorporationAssets) ExpiresOn() *time.Time {
-	return c.expireDate
+// Structure is a player-owned structure, whose name can only be retrieved with an access token
+type Structure struct {
+	StructureID   int64  `json:"structureID" db:"structureID"`
+	Name          string `json:"name" db:"name"`
+	SolarSystemID int32  `json:"solarSystemID" db:"solarSystemID"`
+	TypeID        int32  `json:"typeID" db:"typeID"`
 }
 
-func (c *CorporationAssets) SetExpire(t *time.Time) {
-	c.expireDate = t
+// ResolveAssetLocations follows the chain of locations of every asset, until it reaches a location that is not an
+// asset itself. This is the station, structure or solar system the asset is stored in. On the way, the first
+// corporation hangar flag determines the hangar division of the asset, because items inside containers only have
+// the flag of the container.
+func ResolveAssetLocations(assets []Asset) {
+	items := map[int64]*Asset{}
+
+	for i := range assets {
+		items[assets[i].ItemID] = &assets[i]
+	}
+
+	for i := range assets {
+		asset := &assets[i]
+		current := asset
+
+		// guard against cycles, which should not happen but would otherwise never terminate
+		for depth := 0; depth <= len(assets); depth++ {
+			if asset.Division == 0 {
+				asset.Division = HangarDivision(current.LocationFlag)
+			}
+
+			parent, ok := items[current.LocationID]
+			if !ok {
+				break
+			}
+
+			current = parent
+		}
+
+		asset.RootLocationID = current.LocationID
+	}
 }
 
-func (c *CorporationAssets) HashKey() string {
-	return fmt.Sprintf("corporation-assets:%d", c.ID())
+// HangarDivision returns the corporation hangar division (1-7) of a location flag or 0, if the flag does not
+// belong to a corporation hangar
+func HangarDivision(locationFlag string) int32 {
+	if !strings.HasPrefix(locationFlag, LocationFlagCorporationHangar) {
+		return 0
+	}
+
+	division, err := strconv.Atoi(strings.TrimPrefix(locationFlag, LocationFlagCorporationHangar))
+	if err != nil {
+		return 0
+	}
+
+	return int32(division)
 }
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+)
+
+func TestHangarDivision(t *testing.T) {
+	tests := []struct {
+		name         string
+		locationFlag string
+		want         int32
+	}{
+		{"first division", "CorpSAG1", 1},
+		{"last division", "CorpSAG7", 7},
+		{"hangar of a character", "Hangar", 0},
+		{"delivery hangar", "CorpDeliveries", 0},
+		{"container", "Unlocked", 0},
+		{"malformed", "CorpSAGx", 0},
+		{"empty", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HangarDivision(tt.locationFlag); got != tt.want {
+				t.Errorf("HangarDivision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAssetLocations(t *testing.T) {
+	const (
+		stationID   = 60003760
+		officeID    = 1000
+		containerID = 1001
+	)
+
+	// a root location of -1 is not checked
+	type want struct {
+		rootLocationID int64
+		division       int32
+	}
+	tests := []struct {
+		name   string
+		assets []Asset
+		want   map[int64]want
+	}{
+		{
+			name: "item in a hangar division",
+			assets: []Asset{
+				{ItemID: officeID, LocationID: stationID, LocationFlag: "OfficeFolder"},
+				{ItemID: 1, LocationID: officeID, LocationFlag: "CorpSAG3"},
+			},
+			want: map[int64]want{
+				officeID: {stationID, 0},
+				1:        {stationID, 3},
+			},
+		},
+		{
+			name: "item in a container takes the division of the container",
+			assets: []Asset{
+				{ItemID: officeID, LocationID: stationID, LocationFlag: "OfficeFolder"},
+				{ItemID: containerID, LocationID: officeID, LocationFlag: "CorpSAG2"},
+				{ItemID: 1, LocationID: containerID, LocationFlag: "Unlocked"},
+			},
+			want: map[int64]want{
+				containerID: {stationID, 2},
+				1:           {stationID, 2},
+			},
+		},
+		{
+			name: "item in a solar system",
+			assets: []Asset{
+				{ItemID: 1, LocationID: 30000142, LocationFlag: "AutoFit"},
+			},
+			want: map[int64]want{
+				1: {30000142, 0},
+			},
+		},
+		{
+			name: "cycle terminates",
+			assets: []Asset{
+				{ItemID: 1, LocationID: 2, LocationFlag: "Unlocked"},
+				{ItemID: 2, LocationID: 1, LocationFlag: "CorpSAG5"},
+			},
+			want: map[int64]want{
+				1: {-1, 5},
+				2: {-1, 5},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ResolveAssetLocations(tt.assets)
+
+			for _, asset := range tt.assets {
+				w, ok := tt.want[asset.ItemID]
+				if !ok {
+					continue
+				}
+
+				if w.rootLocationID != -1 && asset.RootLocationID != w.rootLocationID {
+					t.Errorf("ResolveAssetLocations() item %d RootLocationID = %v, want %v", asset.ItemID, asset.RootLocationID, w.rootLocationID)
+				}
+				if asset.Division != w.division {
+					t.Errorf("ResolveAssetLocations() item %d Division = %v, want %v", asset.ItemID, asset.Division, w.division)
+				}
+			}
+		})
+	}
+}
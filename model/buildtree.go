@@ -0,0 +1,53 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+// BuildTree is the fully expanded tree of everything that is needed to build a product, including
+// components and sub-components that can be built themselves
+type BuildTree struct {
+	Root             *BuildTreeNode `json:"root"`
+	TotalCost        float64        `json:"totalCost" bson:"totalCost"`
+	CostPerItem      float64        `json:"costPerItem" bson:"costPerItem"`
+	CriticalPathTime int            `json:"criticalPathTime" bson:"criticalPathTime"`
+}
+
+// BuildTreeNode is one material in the build tree. If it can be built, it contains the materials of its
+// blueprint as children. Both the cost to buy and the cost to build the required quantity are calculated, so
+// that the caller can decide what to do.
+type BuildTreeNode struct {
+	TypeID            int32                         `json:"typeID"`
+	TypeName          string                        `json:"typeName"`
+	Quantity          int                           `json:"quantity"`
+	Buildable         bool                          `json:"buildable"`
+	Build             bool                          `json:"build"`
+	ActivityID        IndustryActivityID            `json:"activityID,omitempty" bson:"activityID"`
+	BlueprintTypeID   int32                         `json:"blueprintTypeID,omitempty" bson:"blueprintTypeID"`
+	UnitsPerRun       int                           `json:"unitsPerRun,omitempty" bson:"unitsPerRun"`
+	Runs              int                           `json:"runs,omitempty"`
+	ME                int64                         `json:"me,omitempty"`
+	TE                int64                         `json:"te,omitempty"`
+	PricePerUnit      float64                       `json:"pricePerUnit" bson:"pricePerUnit"`
+	BuyCost           float64                       `json:"buyCost" bson:"buyCost"`
+	JobCost           float64                       `json:"jobCost" bson:"jobCost"`
+	InventionCost     float64                       `json:"inventionCost,omitempty" bson:"inventionCost"`
+	BuildCost         float64                       `json:"buildCost" bson:"buildCost"`
+	Cost              float64                       `json:"cost"`
+	Time              int                           `json:"time"`
+	RequiredSkills    map[string]ManufacturingSkill `json:"requiredSkills,omitempty" bson:"requiredSkills"`
+	HasRequiredSkills bool                          `json:"hasRequiredSkills" bson:"hasRequiredSkills"`
+	Materials         []*BuildTreeNode              `json:"materials,omitempty"`
+}
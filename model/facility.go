@@ -0,0 +1,92 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	StructureRaitaru    = "Raitaru"
+	StructureAzbel      = "Azbel"
+	StructureSotiyo     = "Sotiyo"
+	StructureNPCStation = "NPC Station"
+	StructureAthanor    = "Athanor"
+	StructureTatara     = "Tatara"
+)
+
+const (
+	SecurityHigh     = "high"
+	SecurityLow      = "low"
+	SecurityNull     = "null"
+	SecurityWormhole = "wormhole"
+)
+
+const (
+	RigBonusMaterial = "material"
+	RigBonusTime     = "time"
+)
+
+// Facility is an industry facility, such as a structure or an NPC station, in which jobs are installed
+type Facility struct {
+	FacilityID    int32        `json:"facilityID" db:"facilityID"`
+	CorporationID int32        `json:"corporationID" db:"corporationID"`
+	Name          string       `json:"name" db:"name"`
+	StructureType string       `json:"structureType" db:"structureType"`
+	SolarSystemID int32        `json:"solarSystemID" db:"solarSystemID"`
+	Security      string       `json:"security" db:"security"`
+	Tax           float64      `json:"tax" db:"tax"`
+	Rigs          FacilityRigs `json:"rigs" db:"rigs"`
+}
+
+// FacilityRig is a rig installed in a structure. It either reduces material consumption or job
+// duration for all products of a certain rig group, such as equipment or advanced medium ships.
+type FacilityRig struct {
+	Group string `json:"group"`
+	Bonus string `json:"bonus"`
+	Tech  int    `json:"tech"`
+}
+
+type FacilityRigs []FacilityRig
+
+func (r FacilityRigs) Value() (driver.Value, error) {
+	if r == nil {
+		r = FacilityRigs{}
+	}
+
+	j, err := json.Marshal(r)
+	return j, err
+}
+
+func (r *FacilityRigs) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("Type assertion .([]byte) failed.")
+	}
+
+	var rigs FacilityRigs
+	err := json.Unmarshal(source, &rigs)
+	if err != nil {
+		return err
+	}
+
+	*r = rigs
+
+	return nil
+}
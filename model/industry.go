@@ -5,12 +5,27 @@ import (
 	"time"
 )
 
+// IndustryActivityNames maps the industry activities of the SDE to the activity names used by ESI
+var IndustryActivityNames = map[IndustryActivityID]string{
+	1:  "manufacturing",
+	3:  "researching_time_efficiency",
+	4:  "researching_material_efficiency",
+	5:  "copying",
+	8:  "invention",
+	11: "reaction",
+}
+
 type SystemCostIndex struct {
 	expireDate    *time.Time
 	ActivityCost  map[string]float32
 	SolarSystemID int32
 }
 
+// CostIndex returns the cost index of the specified activity in this solar system
+func (i *SystemCostIndex) CostIndex(activityID IndustryActivityID) float64 {
+	return float64(i.ActivityCost[IndustryActivityNames[activityID]])
+}
+
 func (i *SystemCostIndex) ID() int32 {
 	return i.SolarSystemID
 }
@@ -54,6 +69,7 @@ type IndustryJob struct {
 	Runs                 int32      `json:"runs" db:"runs"`
 	SuccesfulRuns        int32      `json:"succesfulRuns" db:"succesfulRuns"`
 	Status               string     `json:"status" db:"status"`
+	CorporationID        int32      `json:"corporationID" db:"corporationID"`
 }
 
 type IndustryJobWithTypeNames struct {
@@ -61,3 +77,32 @@ type IndustryJobWithTypeNames struct {
 	BlueprintTypeName string `json:"blueprintTypeName" db:"blueprintTypeName"`
 	ProductTypeName   string `json:"productTypeName" db:"productTypeName"`
 }
+
+// CorporationBlueprint is a blueprint owned by a corporation, as returned by ESI
+type CorporationBlueprint struct {
+	ItemID             int64  `json:"itemID" db:"itemID"`
+	CorporationID      int32  `json:"corporationID" db:"corporationID"`
+	TypeID             int32  `json:"typeID" db:"typeID"`
+	LocationID         int64  `json:"locationID" db:"locationID"`
+	LocationFlag       string `json:"locationFlag" db:"locationFlag"`
+	Quantity           int32  `json:"quantity" db:"quantity"`
+	MaterialEfficiency int32  `json:"materialEfficiency" db:"materialEfficiency"`
+	TimeEfficiency     int32  `json:"timeEfficiency" db:"timeEfficiency"`
+	Runs               int32  `json:"runs" db:"runs"`
+}
+
+// IsCopy returns true, if the blueprint is a blueprint copy. ESI marks copies with a quantity of -2, while
+// originals have a quantity of -1 or the size of the stack.
+func (b CorporationBlueprint) IsCopy() bool {
+	return b.Quantity == -2
+}
+
+type CorporationBlueprints struct {
+	CorporationID int32                                `json:"corporationID"`
+	Blueprints    []*CorporationBlueprintWithTypeNames `json:"blueprints"`
+}
+
+type CorporationBlueprintWithTypeNames struct {
+	*CorporationBlueprint
+	TypeName string `json:"typeName" db:"typeName"`
+}
@@ -13,6 +13,37 @@ type ManufacturingMaterial struct {
 	TypeName     string  `json:"typeName" db:"typeName"`
 	PricePerUnit float64 `json:"pricePerUnit" db:"pricePerUnit"`
 	Cost         float64 `json:"cost"`
+	Volume       float64 `json:"volume" db:"volume"`
+	// OnHand is the quantity that is taken from the inventory and ToBuy the quantity that still needs to be bought
+	OnHand      int     `json:"onHand"`
+	ToBuy       int     `json:"toBuy"`
+	OnHandValue float64 `json:"onHandValue"`
+}
+
+// ShoppingList contains all materials of a manufacturing job that are not on hand and need to be bought
+type ShoppingList struct {
+	Items       []ShoppingListItem `json:"items"`
+	TotalCost   float64            `json:"totalCost"`
+	TotalVolume float64            `json:"totalVolume"`
+}
+
+type ShoppingListItem struct {
+	TypeID       int32   `json:"typeID"`
+	TypeName     string  `json:"typeName"`
+	Quantity     int     `json:"quantity"`
+	PricePerUnit float64 `json:"pricePerUnit"`
+	Cost         float64 `json:"cost"`
+	Volume       float64 `json:"volume"`
+}
+
+// RunCost contains the costs and profit per item of a manufacturing job with a specific number of runs
+type RunCost struct {
+	Runs                int         `json:"runs"`
+	Jobs                int         `json:"jobs"`
+	Units               int         `json:"units"`
+	MaterialCostPerItem float64     `json:"materialCostPerItem"`
+	CostPerItem         float64     `json:"costPerItem"`
+	ProfitPerItem       ProfitValue `json:"profitPerItem"`
 }
 
 type ManufacturingSkill struct {
@@ -35,8 +66,12 @@ type Manufacturing struct {
 	BlueprintType                *Type                            `json:"blueprintType" bson:"blueprintType"`
 	Product                      *Type                            `json:"product"`
 	ProductTypeID                int32                            `json:"productTypeID"`
+	ActivityID                   IndustryActivityID               `json:"activityID" bson:"activityID"`
+	UnitsPerRun                  int                              `json:"unitsPerRun" bson:"unitsPerRun"`
 	IsTech2                      bool                             `json:"isTech2" bson:"isTech2"`
+	IsTech3                      bool                             `json:"isTech3" bson:"isTech3"`
 	Runs                         int                              `json:"runs"`
+	Jobs                         int                              `json:"jobs"`
 	MaxSlots                     int                              `json:"maxSlots" bson:"maxSlots"`
 	SlotsUsed                    int                              `json:"slotsUsed" bson:"slotsUsed"`
 	JobDurationModifiers         map[string]float64               `json:"jobDurationModifiers" bson:"jobDurationModifiers"`
@@ -49,15 +84,21 @@ type Manufacturing struct {
 	RequiredSkills               map[string]ManufacturingSkill    `json:"requiredSkills" bson:"requiredSkills"`
 	HasRequiredSkills            bool                             `json:"hasRequiredSkills" bson:"hasRequiredSkills"`
 	Facility                     string                           `json:"facility"`
+	FacilityID                   int32                            `json:"facilityID" bson:"facilityID"`
+	SolarSystemID                int32                            `json:"solarSystemID" bson:"solarSystemID"`
+	SystemCostIndex              float64                          `json:"systemCostIndex" bson:"systemCostIndex"`
 	Costs                        struct {
 		TotalMaterials float64 `json:"totalMaterials" bson:"totalMaterials"`
 		TotalJobCost   float64 `json:"totalJobCost" bson:"totalJobCost"`
+		TotalFees      float64 `json:"totalFees" bson:"totalFees"`
+		TotalHauling   float64 `json:"totalHauling" bson:"totalHauling"`
 		Total          float64 `json:"total"`
 		PerItem        float64 `json:"perItem" bson:"perItem"`
 	} `json:"costs"`
 	Revenue struct {
 		Total   ProfitValue `json:"total"`
 		PerItem ProfitValue `json:"perItem" bson:"perItem"`
+		Fees    ProfitValue `json:"fees"`
 	} `json:"revenue"`
 	Profit struct {
 		Total   ProfitValue `json:"total"`
@@ -65,11 +106,22 @@ type Manufacturing struct {
 		PerDay  ProfitValue `json:"perDay" bson:"perDay"`
 		Margin  ProfitValue `json:"margin"`
 	} `json:"profit"`
-	BuyOrderVolume int        `json:"buyOrderVolume" bson:"buyOrderVolume"`
-	DailyBuyFactor float64    `json:"dailyBuyFactor" bson:"dailyBuyFactor"`
-	Time           int        `json:"time"`
-	ItemsPerDay    float64    `json:"itemsPerDay" bson:"itemsPerDay"`
-	Invention      *Invention `json:"invention"`
+	BuyOrderVolume   int                    `json:"buyOrderVolume" bson:"buyOrderVolume"`
+	DailyBuyFactor   float64                `json:"dailyBuyFactor" bson:"dailyBuyFactor"`
+	Demand           *MarketDemand          `json:"demand"`
+	Time             int                    `json:"time"`
+	ItemsPerDay      float64                `json:"itemsPerDay" bson:"itemsPerDay"`
+	Invention        *Invention             `json:"invention"`
+	DecryptorProfits map[string]ProfitValue `json:"decryptorProfits,omitempty" bson:"decryptorProfits"`
+	RelicProfits     map[string]ProfitValue `json:"relicProfits,omitempty" bson:"relicProfits"`
+	HasBlueprint     bool                   `json:"hasBlueprint" bson:"hasBlueprint"`
+	Hub              TradeHub               `json:"hub"`
+	MaterialStrategy PriceStrategy          `json:"materialStrategy" bson:"materialStrategy"`
+	ProductStrategy  PriceStrategy          `json:"productStrategy" bson:"productStrategy"`
+	Fees             MarketFees             `json:"fees"`
+	Hauling          HaulingRate            `json:"hauling"`
+	OwnedBlueprint   *CorporationBlueprint  `json:"ownedBlueprint" bson:"ownedBlueprint"`
+	ShoppingList     *ShoppingList          `json:"shoppingList,omitempty" bson:"shoppingList"`
 }
 
 func (m Manufacturing) ID() int32 {
@@ -91,17 +143,35 @@ func (m Manufacturing) HashKey() string {
 type ProfitValue struct {
 	BasedOnBuyPrice  float64 `json:"basedOnBuyPrice" bson:"basedOnBuyPrice"`
 	BasedOnSellPrice float64 `json:"basedOnSellPrice" bson:"basedOnSellPrice"`
+	BasedOnStrategy  float64 `json:"basedOnStrategy" bson:"basedOnStrategy"`
 }
 
 type Invention struct {
 	BlueprintType               *Type                            `json:"blueprintType" bson:"blueprintType"`
+	IsReverseEngineering        bool                             `json:"isReverseEngineering" bson:"isReverseEngineering"`
 	CostsPerInvention           int                              `json:"costsPerInvention" bson:"costsPerInvention"`
 	DecryptorTypeID             int32                            `json:"decryptorTypeID" bson:"decryptorTypeID"`
+	Decryptor                   *Decryptor                       `json:"decryptor" bson:"decryptor"`
+	Runs                        int                              `json:"runs"`
+	ME                          int64                            `json:"me"`
+	TE                          int64                            `json:"te"`
 	Materials                   map[string]ManufacturingMaterial `json:"materials"`
 	RequiredSkills              map[string]ManufacturingSkill    `json:"requiredSkills" bson:"requiredSkills"`
 	SuccessProbabilityModifiers map[string]float64               `json:"successProbabilityModifiers" bson:"successProbabilityModifiers"`
+	SystemCostIndex             float64                          `json:"systemCostIndex" bson:"systemCostIndex"`
+	JobCost                     float64                          `json:"jobCost" bson:"jobCost"`
 	CostsPerRun                 float64                          `json:"costsPerRun" bson:"costsPerRun"`
 	InventionChance             float64                          `json:"inventionChance" bson:"inventionChance"`
 	TriesForManufacturing       float64                          `json:"triesForManufacturing" bson:"triesForManufacturing"`
 	CostsForManufacturing       float64                          `json:"costsForManufacturing" bson:"costsForManufacturing"`
 }
+
+// Decryptor holds the modifiers a decryptor applies to an invention job
+type Decryptor struct {
+	TypeID                int32   `json:"typeID"`
+	TypeName              string  `json:"typeName"`
+	ProbabilityMultiplier float64 `json:"probabilityMultiplier" bson:"probabilityMultiplier"`
+	MaxRunModifier        int     `json:"maxRunModifier" bson:"maxRunModifier"`
+	MEModifier            int64   `json:"meModifier" bson:"meModifier"`
+	TEModifier            int64   `json:"teModifier" bson:"teModifier"`
+}
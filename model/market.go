@@ -23,8 +23,67 @@ import (
 
 const (
 	JitaRegionID = 10000002
+	JitaSystemID = 30000142
 )
 
+const (
+	TradeHubJita    = "jita"
+	TradeHubAmarr   = "amarr"
+	TradeHubDodixie = "dodixie"
+	TradeHubRens    = "rens"
+	TradeHubHek     = "hek"
+)
+
+// TradeHub is the market location whose orders are used for prices. If it has no station, all orders
+// within the region are used.
+type TradeHub struct {
+	Name      string `json:"name" db:"hubName"`
+	RegionID  int32  `json:"regionID" db:"hubRegionID"`
+	StationID int64  `json:"stationID" db:"hubStationID"`
+}
+
+// LocationID returns the station of the trade hub or its region, if it has no station
+func (h TradeHub) LocationID() int64 {
+	if h.StationID != 0 {
+		return h.StationID
+	}
+
+	return int64(h.RegionID)
+}
+
+// TradeHubs contains the main trade hubs of New Eden
+var TradeHubs = map[string]TradeHub{
+	TradeHubJita:    {Name: "Jita IV - Moon 4 - Caldari Navy Assembly Plant", RegionID: JitaRegionID, StationID: 60003760},
+	TradeHubAmarr:   {Name: "Amarr VIII (Oris) - Emperor Family Academy", RegionID: 10000043, StationID: 60008494},
+	TradeHubDodixie: {Name: "Dodixie IX - Moon 20 - Federation Navy Assembly Plant", RegionID: 10000032, StationID: 60011866},
+	TradeHubRens:    {Name: "Rens VI - Moon 8 - Brutor Tribe Treasury", RegionID: 10000030, StationID: 60004588},
+	TradeHubHek:     {Name: "Hek VIII - Moon 12 - Boundless Creation Factory", RegionID: 10000042, StationID: 60005686},
+}
+
+// PriceStrategy specifies which value of the aggregated orders is used as price
+type PriceStrategy string
+
+const (
+	// PriceStrategyBuy uses the top of the buy orders, i.e. placing a buy order or selling to buy orders
+	PriceStrategyBuy = PriceStrategy("buy")
+	// PriceStrategySell uses the bottom of the sell orders, i.e. buying from sell orders or placing a sell order
+	PriceStrategySell = PriceStrategy("sell")
+	// PriceStrategyWeightedAverage uses the weighted average of all sell orders
+	PriceStrategyWeightedAverage = PriceStrategy("weightedAverage")
+	// PriceStrategyMedian uses the median of all sell orders
+	PriceStrategyMedian = PriceStrategy("median")
+)
+
+// IsValid returns true, if the price strategy is known
+func (s PriceStrategy) IsValid() bool {
+	switch s {
+	case PriceStrategyBuy, PriceStrategySell, PriceStrategyWeightedAverage, PriceStrategyMedian:
+		return true
+	}
+
+	return false
+}
+
 type MarketPrice struct {
 	expireDate    *time.Time
 	AdjustedPrice float64 `json:"adjustedPrice"`
@@ -51,10 +110,25 @@ func (c *MarketPrice) HashKey() string {
 type Price struct {
 	expireDate *time.Time
 	TypeID     int32
+	LocationID int64
 	Buy        PriceData
 	Sell       PriceData
 }
 
+// Value returns the price according to the specified strategy
+func (c Price) Value(strategy PriceStrategy) float64 {
+	switch strategy {
+	case PriceStrategyBuy:
+		return c.Buy.Percentile
+	case PriceStrategyWeightedAverage:
+		return c.Sell.WeightedAverage
+	case PriceStrategyMedian:
+		return c.Sell.Median
+	default:
+		return c.Sell.Percentile
+	}
+}
+
 type PriceData struct {
 	WeightedAverage float64 `json:"weightedAverage,string"`
 	Max             float64 `json:"max,string"`
@@ -79,5 +153,88 @@ func (c *Price) SetExpire(t *time.Time) {
 }
 
 func (c *Price) HashKey() string {
-	return fmt.Sprintf("price:%d", c.ID())
+	return PriceHashKey(c.LocationID, c.ID())
+}
+
+// PriceHashKey returns the cache key of the price of a type at a location
+func PriceHashKey(locationID int64, typeID int32) string {
+	return fmt.Sprintf("price:%d:%d", locationID, typeID)
+}
+
+// MarketFees contains the fees that are charged by the market, as share of the order value
+type MarketFees struct {
+	SalesTax  float64 `json:"salesTax"`
+	BrokerFee float64 `json:"brokerFee"`
+}
+
+// SellFee returns the fees of selling with the specified strategy. Selling to buy orders only costs sales tax,
+// while placing a sell order costs the broker fee as well.
+func (f MarketFees) SellFee(strategy PriceStrategy) float64 {
+	if strategy == PriceStrategyBuy {
+		return f.SalesTax
+	}
+
+	return f.SalesTax + f.BrokerFee
+}
+
+// BuyFee returns the fees of buying with the specified strategy. Buying from sell orders is free, while placing
+// a buy order costs the broker fee.
+func (f MarketFees) BuyFee(strategy PriceStrategy) float64 {
+	if strategy == PriceStrategyBuy {
+		return f.BrokerFee
+	}
+
+	return 0
+}
+
+// HaulingRate specifies the costs of hauling items between the build location and the trade hub. Haulers
+// usually charge per m³, a percentage of the collateral or both.
+type HaulingRate struct {
+	PricePerM3     float64 `json:"pricePerM3"`
+	CollateralRate float64 `json:"collateralRate"`
+}
+
+// Cost returns the costs of hauling items with the specified volume and value
+func (r HaulingRate) Cost(volume float64, value float64) float64 {
+	return volume*r.PricePerM3 + value*r.CollateralRate
+}
+
+// PriceHistoryEntry contains the aggregated prices of a type on a single day
+type PriceHistoryEntry struct {
+	Date           time.Time `json:"date" db:"date"`
+	BuyPercentile  float64   `json:"buyPercentile" db:"buyPercentile"`
+	BuyVolume      int64     `json:"buyVolume" db:"buyVolume"`
+	BuyOrderCount  int       `json:"buyOrderCount" db:"buyOrderCount"`
+	SellPercentile float64   `json:"sellPercentile" db:"sellPercentile"`
+	SellVolume     int64     `json:"sellVolume" db:"sellVolume"`
+	SellOrderCount int       `json:"sellOrderCount" db:"sellOrderCount"`
+}
+
+type PriceHistory struct {
+	TypeID     int32               `json:"typeID"`
+	LocationID int64               `json:"locationID"`
+	History    []PriceHistoryEntry `json:"history"`
+}
+
+// MarketHistoryEntry contains the trades of a type in a region on a single day, as reported by ESI
+type MarketHistoryEntry struct {
+	RegionID   int32     `json:"regionID" db:"regionID"`
+	TypeID     int32     `json:"typeID" db:"typeID"`
+	Date       time.Time `json:"date" db:"date"`
+	Average    float64   `json:"average" db:"average"`
+	Highest    float64   `json:"highest" db:"highest"`
+	Lowest     float64   `json:"lowest" db:"lowest"`
+	OrderCount int64     `json:"orderCount" db:"orderCount"`
+	Volume     int64     `json:"volume" db:"volume"`
+}
+
+// MarketDemand estimates how many units of a type the market can absorb
+type MarketDemand struct {
+	// AverageDailyVolume30 and AverageDailyVolume90 are the average units traded per day over the last 30 and 90 days
+	AverageDailyVolume30 float64 `json:"averageDailyVolume30" db:"averageDailyVolume30"`
+	AverageDailyVolume90 float64 `json:"averageDailyVolume90" db:"averageDailyVolume90"`
+	// DaysOfSupply is the number of days the market needs to absorb the output of one job
+	DaysOfSupply float64 `json:"daysOfSupply"`
+	// DailyShare is the share of the daily traded volume that our daily output represents
+	DailyShare float64 `json:"dailyShare"`
 }
@@ -0,0 +1,65 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+// ProductionPlanRequest contains the constraints of a production plan
+type ProductionPlanRequest struct {
+	Characters []PlanCharacter `json:"characters"`
+	// Days is the time window of the plan. Jobs that would take longer are not planned.
+	Days float64 `json:"days"`
+	// Budget is the capital that can be spent on materials and job costs. If it is 0, the budget is unlimited.
+	Budget float64 `json:"budget"`
+	// TypeIDs are the products that are considered. If empty, the most profitable products are used.
+	TypeIDs []int32 `json:"typeIDs"`
+	// DemandLimits caps the number of units per product type
+	DemandLimits map[string]int `json:"demandLimits"`
+	// MaxMarketShare caps the units of products without demand limit to this share of the traded volume
+	// during the time window. If it is 0, products without demand limit are not capped.
+	MaxMarketShare float64 `json:"maxMarketShare"`
+}
+
+// PlanCharacter is a character whose manufacturing slots are used in a production plan
+type PlanCharacter struct {
+	CharacterID int32 `json:"characterID"`
+	// Slots is the number of available slots. If it is 0, all slots granted by the character's skills are used.
+	Slots int `json:"slots"`
+	// ReactionSlots is the number of available reaction slots. If it is 0, all reaction slots granted by the
+	// character's skills are used.
+	ReactionSlots int `json:"reactionSlots"`
+}
+
+// ProductionPlan is an allocation of manufacturing jobs to the slots of characters
+type ProductionPlan struct {
+	Jobs        []PlannedJob `json:"jobs"`
+	TotalCost   float64      `json:"totalCost"`
+	TotalProfit float64      `json:"totalProfit"`
+	UsedSlots   int          `json:"usedSlots"`
+	FreeSlots   int          `json:"freeSlots"`
+}
+
+// PlannedJob is a single manufacturing job in a production plan
+type PlannedJob struct {
+	CharacterID     int32              `json:"characterID"`
+	ActivityID      IndustryActivityID `json:"activityID"`
+	ProductTypeID   int32              `json:"productTypeID"`
+	ProductTypeName string             `json:"productTypeName"`
+	Runs            int                `json:"runs"`
+	Units           int                `json:"units"`
+	Duration        int                `json:"duration"`
+	Cost            float64            `json:"cost"`
+	Profit          float64            `json:"profit"`
+}
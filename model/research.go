@@ -0,0 +1,54 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+// Research contains the time and costs to research a blueprint from its current to a target ME and TE, as well
+// as the number of runs after which the material efficiency research pays for itself
+type Research struct {
+	BlueprintType            *Type           `json:"blueprintType" bson:"blueprintType"`
+	ProductTypeID            int32           `json:"productTypeID" bson:"productTypeID"`
+	CurrentME                int64           `json:"currentME" bson:"currentME"`
+	TargetME                 int64           `json:"targetME" bson:"targetME"`
+	CurrentTE                int64           `json:"currentTE" bson:"currentTE"`
+	TargetTE                 int64           `json:"targetTE" bson:"targetTE"`
+	MaterialEfficiency       []ResearchLevel `json:"materialEfficiency" bson:"materialEfficiency"`
+	TimeEfficiency           []ResearchLevel `json:"timeEfficiency" bson:"timeEfficiency"`
+	Copying                  *Copying        `json:"copying,omitempty"`
+	TotalTime                int             `json:"totalTime" bson:"totalTime"`
+	TotalJobCost             float64         `json:"totalJobCost" bson:"totalJobCost"`
+	MaterialCostPerRun       float64         `json:"materialCostPerRun" bson:"materialCostPerRun"`
+	TargetMaterialCostPerRun float64         `json:"targetMaterialCostPerRun" bson:"targetMaterialCostPerRun"`
+	SavingsPerRun            float64         `json:"savingsPerRun" bson:"savingsPerRun"`
+	TimeSavedPerRun          int             `json:"timeSavedPerRun" bson:"timeSavedPerRun"`
+	BreakEvenRuns            int             `json:"breakEvenRuns" bson:"breakEvenRuns"`
+}
+
+// ResearchLevel is one level of material or time efficiency research
+type ResearchLevel struct {
+	Level   int     `json:"level"`
+	Value   int64   `json:"value"`
+	Time    int     `json:"time"`
+	JobCost float64 `json:"jobCost" bson:"jobCost"`
+}
+
+// Copying contains the time and costs to copy a blueprint
+type Copying struct {
+	Copies  int     `json:"copies"`
+	Runs    int     `json:"runs"`
+	Time    int     `json:"time"`
+	JobCost float64 `json:"jobCost" bson:"jobCost"`
+}
@@ -0,0 +1,26 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+// Settings are the personal settings of a character, which are used as defaults for all calculations
+type Settings struct {
+	CharacterID int32 `json:"characterID" db:"characterID"`
+	TradeHub    `json:"hub"`
+	// MaterialStrategy and ProductStrategy specify how materials are bought and products are sold
+	MaterialStrategy PriceStrategy `json:"materialStrategy" db:"materialStrategy"`
+	ProductStrategy  PriceStrategy `json:"productStrategy" db:"productStrategy"`
+}
@@ -87,6 +87,10 @@ func (t Type) IsTechII() bool {
 	return t.MetaGroupID != nil && *t.MetaGroupID == 2
 }
 
+func (t Type) IsTechIII() bool {
+	return t.MetaGroupID != nil && *t.MetaGroupID == 14
+}
+
 type Group struct {
 	GroupID    int32  `json:"groupID" db:"groupID"`
 	CategoryID int32  `json:"categoryID" db:"categoryID" yaml:"categoryID"`
@@ -33,6 +33,20 @@ type Wallet struct {
 	Balance  float64 `json:"balance"`
 }
 
+// WalletBalance is the balance of a wallet division at a point in time
+type WalletBalance struct {
+	CorporationID int32     `json:"corporationID" db:"corporationID"`
+	Division      int32     `json:"division" db:"division"`
+	Date          time.Time `json:"date" db:"date"`
+	Balance       float64   `json:"balance" db:"balance"`
+}
+
+// BalanceHistory is the daily closing balance of the wallet divisions of a corporation
+type BalanceHistory struct {
+	CorporationID int32           `json:"corporationID"`
+	Balances      []WalletBalance `json:"balances"`
+}
+
 type JournalEntry struct {
 	Amount        float64
 	Balance       float64
@@ -44,6 +58,32 @@ type JournalEntry struct {
 	SecondPartyID int32  `json:"secondPartyID" db:"secondPartyID"`
 	CorporationID int32  `json:"corporationID" db:"corporationID"`
 	Division      int32  `json:"division" db:"division"`
+	// ContextID is the ID of the object the entry is about, such as a market transaction or an industry job.
+	// Its kind is specified by ContextIDType.
+	ContextID     int64   `json:"contextID" db:"contextID"`
+	ContextIDType string  `json:"contextIDType" db:"contextIDType"`
+	Reason        string  `json:"reason" db:"reason"`
+	Tax           float64 `json:"tax" db:"tax"`
+	TaxReceiverID int32   `json:"taxReceiverID" db:"taxReceiverID"`
+}
+
+// ContextIDTypeMarketTransaction is the context ID type of journal entries that belong to a market transaction
+const ContextIDTypeMarketTransaction = "market_transaction_id"
+
+// CorporationJournal are the journal entries of a corporation
+type CorporationJournal struct {
+	CorporationID int32                          `json:"corporationID"`
+	Entries       []*JournalEntryWithTransaction `json:"entries"`
+}
+
+// JournalEntryWithTransaction is a journal entry together with the market transaction it belongs to, if any
+type JournalEntryWithTransaction struct {
+	*JournalEntry
+	TransactionID *int64   `json:"transactionID" db:"transactionID"`
+	TypeID        *int32   `json:"typeID" db:"typeID"`
+	TypeName      *string  `json:"typeName" db:"typeName"`
+	Quantity      *int     `json:"quantity" db:"quantity"`
+	UnitPrice     *float64 `json:"unitPrice" db:"unitPrice"`
 }
 
 type Transaction struct {
@@ -15,6 +15,8 @@ pg_restore -U $USER -h $HOST \
 -t industryActivityProbabilities \
 -t industryActivitySkills \
 -t industryActivityMaterials \
+-t staStations \
+-t mapSolarSystems \
 -d titan sde-$VERSION
 
 # Our tables
@@ -27,7 +27,7 @@ import (
 )
 
 func Login(c *gin.Context) {
-	scope := "publicData esi-skills.read_skills.v1 esi-corporations.read_corporation_membership.v1 esi-ui.open_window.v1 esi-wallet.read_corporation_wallets.v1 esi-assets.read_corporation_assets.v1 esi-corporations.read_blueprints.v1 esi-industry.read_corporation_jobs.v1"
+	scope := "publicData esi-skills.read_skills.v1 esi-corporations.read_corporation_membership.v1 esi-ui.open_window.v1 esi-wallet.read_corporation_wallets.v1 esi-assets.read_corporation_assets.v1 esi-corporations.read_blueprints.v1 esi-industry.read_corporation_jobs.v1 esi-characters.read_corporation_roles.v1 esi-universe.read_structures.v1"
 
 	t := time.Now()
 	state := base64.StdEncoding.EncodeToString([]byte(t.String()))
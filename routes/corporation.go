@@ -17,13 +17,32 @@ limitations under the License.
 package routes
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oxisto/titan/accounting"
 	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
 	"github.com/oxisto/titan/model"
 )
 
+const (
+	QueryParamPeriod = "period"
+	QueryParamFormat = "format"
+
+	// FormatCSV returns reports as CSV file instead of JSON
+	FormatCSV = "csv"
+
+	// DefaultReportDays is the time range of reports, if none is specified
+	DefaultReportDays = 90
+
+	// DefaultJournalDays is the time range of the journal, if none is specified
+	DefaultJournalDays = 30
+)
+
 func GetCorporation(c *gin.Context) {
 	character := c.Value(CharacterContext).(*model.Character)
 	corporation := &model.Corporation{}
@@ -41,3 +60,229 @@ func GetCorporationWallets(c *gin.Context) {
 
 	JSON(c, http.StatusOK, wallets, err)
 }
+
+// GetCorporationAssets returns the assets of the caller's corporation. They can be filtered by location, type
+// and hangar division using the locationID, typeID and division query parameters.
+func GetCorporationAssets(c *gin.Context) {
+	var filter db.AssetFilter
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if locationID, err := IntQuery(c, "locationID"); err == nil {
+		filter.LocationID = locationID
+	}
+
+	if typeID, err := IntQuery(c, "typeID"); err == nil {
+		filter.TypeID = int32(typeID)
+	}
+
+	if division, err := IntQuery(c, "division"); err == nil {
+		filter.Division = int32(division)
+	}
+
+	assetList, err := db.GetCorporationAssets(character.CorporationID, filter)
+
+	assets := model.CorporationAssets{
+		CorporationID: character.CorporationID,
+		Assets:        assetList,
+	}
+
+	JSON(c, http.StatusOK, assets, err)
+}
+
+// GetCorporationJournal returns the wallet journal of the caller's corporation, together with the market
+// transactions the entries belong to. The entries can be filtered by wallet division and time range using the
+// division, from and to query parameters.
+func GetCorporationJournal(c *gin.Context) {
+	var (
+		division int32
+		from     time.Time
+		to       time.Time
+		err      error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if d, err := IntQuery(c, "division"); err == nil {
+		division = int32(d)
+	}
+
+	if from, to, err = DateRangeQuery(c, DefaultJournalDays); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	journal := model.CorporationJournal{
+		CorporationID: character.CorporationID,
+	}
+
+	journal.Entries, err = db.GetJournalEntries(character.CorporationID, division, from, to)
+
+	JSON(c, http.StatusOK, journal, err)
+}
+
+// GetCorporationHoldings returns the items the caller's corporation holds according to its transactions and
+// industry jobs, together with their cost basis and unrealized gain. The cost basis method is specified with the
+// method query parameter, the market value is specified as in MarketValueQuery.
+func GetCorporationHoldings(c *gin.Context) {
+	var (
+		holdings *model.Holdings
+		hub      model.TradeHub
+		strategy model.PriceStrategy
+		method   = model.CostBasisFIFO
+		err      error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if hub, strategy, err = MarketValueQuery(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if m := model.CostBasisMethod(c.Query(QueryParamCostBasisMethod)); m != "" {
+		if !m.IsValid() {
+			JSON(c, http.StatusBadRequest, nil, fmt.Errorf("unknown cost basis method %q", m))
+			return
+		}
+
+		method = m
+	}
+
+	holdings, err = accounting.NewHoldings(character.CorporationID, method, hub, strategy)
+
+	JSON(c, http.StatusOK, holdings, err)
+}
+
+// MarketValueQuery parses the trade hub and price strategy that items are valued with. They default to the trade
+// hub and product strategy of the caller's settings, which can be overridden with the hub and productStrategy
+// query parameters.
+func MarketValueQuery(c *gin.Context, character *model.Character) (hub model.TradeHub, strategy model.PriceStrategy, err error) {
+	hub = manufacturing.DefaultTradeHub
+	strategy = model.PriceStrategySell
+
+	if settings, err := db.GetSettings(character.CharacterID); err == nil {
+		hub = settings.TradeHub
+		strategy = settings.ProductStrategy
+	}
+
+	if hub, err = TradeHubQuery(c, hub); err != nil {
+		return hub, strategy, err
+	}
+
+	if s := model.PriceStrategy(c.Query(QueryParamProductStrategy)); s != "" {
+		if !s.IsValid() {
+			return hub, strategy, fmt.Errorf("unknown price strategy %q", s)
+		}
+
+		strategy = s
+	}
+
+	return hub, strategy, nil
+}
+
+// GetBalanceHistory returns the daily closing balance of the wallet divisions of the caller's corporation. The
+// balances can be filtered by wallet division and time range using the division, from and to query parameters.
+func GetBalanceHistory(c *gin.Context) {
+	var (
+		division int32
+		from     time.Time
+		to       time.Time
+		err      error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if d, err := IntQuery(c, "division"); err == nil {
+		division = int32(d)
+	}
+
+	if from, to, err = DateRangeQuery(c, DefaultReportDays); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	history := model.BalanceHistory{
+		CorporationID: character.CorporationID,
+	}
+
+	history.Balances, err = db.GetBalanceHistory(character.CorporationID, division, from, to)
+
+	JSON(c, http.StatusOK, history, err)
+}
+
+// PostCashFlowForecast projects the wallet balance of the caller's corporation for the days and scheduled
+// expenses in the request body. The products of running jobs are valued as specified in MarketValueQuery.
+func PostCashFlowForecast(c *gin.Context) {
+	var (
+		request  model.CashFlowForecastRequest
+		forecast *model.CashFlowForecast
+		hub      model.TradeHub
+		strategy model.PriceStrategy
+		err      error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if err = c.BindJSON(&request); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if hub, strategy, err = MarketValueQuery(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	forecast, err = accounting.NewCashFlowForecast(character.CorporationID, &request, hub, strategy)
+
+	JSON(c, http.StatusOK, forecast, err)
+}
+
+// GetProfitAndLossReport returns the income and expenses of the caller's corporation out of its wallet journal.
+// The entries are grouped by the period query parameter (day, week or month) and can be limited to a wallet
+// division. The time range is specified with the from and to query parameters. If the format query parameter is
+// csv, the report is returned as CSV file instead of JSON.
+func GetProfitAndLossReport(c *gin.Context) {
+	var (
+		report   *model.ProfitAndLoss
+		period   = model.ReportPeriodMonth
+		division int32
+		from     time.Time
+		to       time.Time
+		err      error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if p := model.ReportPeriod(c.Query(QueryParamPeriod)); p != "" {
+		if !p.IsValid() {
+			JSON(c, http.StatusBadRequest, nil, fmt.Errorf("unknown report period %q", p))
+			return
+		}
+
+		period = p
+	}
+
+	if d, err := IntQuery(c, "division"); err == nil {
+		division = int32(d)
+	}
+
+	if from, to, err = DateRangeQuery(c, DefaultReportDays); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if report, err = accounting.NewProfitAndLoss(character.CorporationID, period, division, from, to); err != nil || c.Query(QueryParamFormat) != FormatCSV {
+		JSON(c, http.StatusOK, report, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"pnl-%d-%s.csv\"", character.CorporationID, from.Format("2006-01-02")))
+	c.Status(http.StatusOK)
+
+	if err = accounting.WriteProfitAndLossCSV(c.Writer, report); err != nil {
+		log.Errorf("Could not write profit and loss report: %v", err)
+	}
+}
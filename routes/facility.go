@@ -0,0 +1,124 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
+	"github.com/oxisto/titan/model"
+)
+
+func GetFacilities(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+
+	facilities, err := db.GetFacilities(character.CorporationID)
+
+	JSON(c, http.StatusOK, facilities, err)
+}
+
+func GetFacility(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+
+	facilityID, err := IntParam(c, "id")
+	if err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	facility, err := db.GetFacility(character.CorporationID, int32(facilityID))
+	if err == sql.ErrNoRows {
+		JSON(c, http.StatusNotFound, nil, nil)
+		return
+	}
+
+	JSON(c, http.StatusOK, facility, err)
+}
+
+func PostFacility(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+	facility := &model.Facility{}
+
+	if err := c.BindJSON(facility); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if err := manufacturing.ValidateFacility(facility); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	facility.CorporationID = character.CorporationID
+
+	err := db.InsertFacility(facility)
+
+	JSON(c, http.StatusOK, facility, err)
+}
+
+func PutFacility(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+	facility := &model.Facility{}
+
+	facilityID, err := IntParam(c, "id")
+	if err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if err := c.BindJSON(facility); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if err := manufacturing.ValidateFacility(facility); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	facility.FacilityID = int32(facilityID)
+	facility.CorporationID = character.CorporationID
+
+	err = db.UpdateFacility(facility)
+	if err == sql.ErrNoRows {
+		JSON(c, http.StatusNotFound, nil, nil)
+		return
+	}
+
+	JSON(c, http.StatusOK, facility, err)
+}
+
+func DeleteFacility(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+
+	facilityID, err := IntParam(c, "id")
+	if err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	err = db.DeleteFacility(character.CorporationID, int32(facilityID))
+	if err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -20,6 +20,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/oxisto/titan/accounting"
 	"github.com/oxisto/titan/db"
 	"github.com/oxisto/titan/model"
 )
@@ -36,3 +37,25 @@ func GetIndustryJobs(c *gin.Context) {
 
 	JSON(c, http.StatusOK, jobs, err)
 }
+
+func GetCorporationBlueprints(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+
+	blueprintList, err := db.GetCorporationBlueprints(character.CorporationID)
+
+	blueprints := model.CorporationBlueprints{
+		CorporationID: character.CorporationID,
+		Blueprints:    blueprintList,
+	}
+
+	JSON(c, http.StatusOK, blueprints, err)
+}
+
+// GetRealizedProfit returns the profit of delivered industry jobs, based on the transactions of the corporation
+func GetRealizedProfit(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+
+	profit, err := accounting.NewRealizedProfit(character.CorporationID)
+
+	JSON(c, http.StatusOK, profit, err)
+}
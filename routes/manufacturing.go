@@ -17,6 +17,8 @@ limitations under the License.
 package routes
 
 import (
+	"database/sql"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -36,10 +38,43 @@ const (
 	QueryParamME                    = "ME"
 	QueryParamTE                    = "TE"
 	QueryParamFacilityTax           = "facilityTax"
+	QueryParamSolarSystemID         = "solarSystemID"
+	QueryParamFacilityID            = "facilityID"
+	QueryParamReactionFacilityID    = "reactionFacilityID"
+	QueryParamRuns                  = "runs"
+	QueryParamBuild                 = "build"
+	QueryParamBuy                   = "buy"
+	QueryParamTargetME              = "targetME"
+	QueryParamTargetTE              = "targetTE"
+	QueryParamCopies                = "copies"
+	QueryParamCopyRuns              = "copyRuns"
+	QueryParamDecryptorTypeID       = "decryptorTypeID"
+	QueryParamOptimizeDecryptor     = "optimizeDecryptor"
+	QueryParamRelicTypeID           = "relicTypeID"
+	QueryParamOwnedBlueprints       = "ownedBlueprints"
+	QueryParamOwnedBlueprintsOnly   = "ownedBlueprintsOnly"
+	QueryParamHub                   = "hub"
+	QueryParamHubRegionID           = "hubRegionID"
+	QueryParamHubStationID          = "hubStationID"
+	QueryParamMaterialStrategy      = "materialStrategy"
+	QueryParamProductStrategy       = "productStrategy"
+	QueryParamFactionStanding       = "factionStanding"
+	QueryParamCorporationStanding   = "corporationStanding"
+	QueryParamSalesTax              = "salesTax"
+	QueryParamBrokerFee             = "brokerFee"
+	QueryParamHaulingPricePerM3     = "haulingPricePerM3"
+	QueryParamHaulingCollateralRate = "haulingCollateralRate"
+	QueryParamInventoryLocationID   = "inventoryLocationID"
+	QueryParamInventoryDivision     = "inventoryDivision"
+	QueryParamInventoryValuation    = "inventoryValuation"
+	QueryParamJobs                  = "jobs"
+	QueryParamRunCounts             = "runCounts"
+	QueryParamCostBasisMethod       = "method"
 
 	RouteVarsTypeID = "typeID"
 
 	SeparatorCategoryIDs = ","
+	SeparatorTypeIDs     = ","
 	SeparatorSortBy      = ":"
 )
 
@@ -55,18 +90,183 @@ type ManufacturingResponse struct {
 	Manufacturing *model.Manufacturing
 }
 
+// ManufacturingOptions parses all manufacturing options out of the query parameters of a request. Options that
+// are not specified keep their default value.
+func ManufacturingOptions(c *gin.Context, character *model.Character) (options *manufacturing.Options, err error) {
+	options = manufacturing.NewOptions()
+	options.OptimizeDecryptor, _ = strconv.ParseBool(c.Query(QueryParamOptimizeDecryptor))
+
+	// the personal settings replace the defaults, but can be overridden by the query parameters
+	if settings, err := db.GetSettings(character.CharacterID); err == nil {
+		options.Hub = settings.TradeHub
+		options.MaterialStrategy = settings.MaterialStrategy
+		options.ProductStrategy = settings.ProductStrategy
+	} else if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("could not retrieve settings: %w", err)
+	}
+
+	if options.Hub, err = TradeHubQuery(c, options.Hub); err != nil {
+		return nil, err
+	}
+
+	if strategy := model.PriceStrategy(c.Query(QueryParamMaterialStrategy)); strategy != "" {
+		if !strategy.IsValid() {
+			return nil, fmt.Errorf("unknown price strategy %q", strategy)
+		}
+
+		options.MaterialStrategy = strategy
+	}
+
+	if strategy := model.PriceStrategy(c.Query(QueryParamProductStrategy)); strategy != "" {
+		if !strategy.IsValid() {
+			return nil, fmt.Errorf("unknown price strategy %q", strategy)
+		}
+
+		options.ProductStrategy = strategy
+	}
+
+	if standing, err := FloatQuery(c, QueryParamFactionStanding); err == nil {
+		options.FactionStanding = standing
+	}
+
+	if standing, err := FloatQuery(c, QueryParamCorporationStanding); err == nil {
+		options.CorporationStanding = standing
+	}
+
+	// explicit fees replace the ones derived from skills and standings
+	salesTax, salesTaxErr := FloatQuery(c, QueryParamSalesTax)
+	brokerFee, brokerFeeErr := FloatQuery(c, QueryParamBrokerFee)
+
+	if salesTaxErr == nil || brokerFeeErr == nil {
+		options.Fees = manufacturing.NewMarketFees(character, options.FactionStanding, options.CorporationStanding)
+
+		if salesTaxErr == nil {
+			options.Fees.SalesTax = salesTax
+		}
+
+		if brokerFeeErr == nil {
+			options.Fees.BrokerFee = brokerFee
+		}
+	}
+
+	if pricePerM3, err := FloatQuery(c, QueryParamHaulingPricePerM3); err == nil {
+		options.Hauling.PricePerM3 = pricePerM3
+	}
+
+	if collateralRate, err := FloatQuery(c, QueryParamHaulingCollateralRate); err == nil {
+		options.Hauling.CollateralRate = collateralRate
+	}
+
+	// the blueprints of the corporation are used, unless explicitly disabled
+	if owned, err := strconv.ParseBool(c.Query(QueryParamOwnedBlueprints)); err != nil || owned {
+		options.CorporationID = character.CorporationID
+	}
+
+	if ME, err := IntQuery(c, QueryParamME); err == nil {
+		options.ME = ME
+	}
+
+	if TE, err := IntQuery(c, QueryParamTE); err == nil {
+		options.TE = TE
+	}
+
+	if facilityTax, err := FloatQuery(c, QueryParamFacilityTax); err == nil {
+		options.FacilityTax = facilityTax
+	}
+
+	if solarSystemID, err := IntQuery(c, QueryParamSolarSystemID); err == nil {
+		options.SolarSystemID = int32(solarSystemID)
+	}
+
+	if facilityID, err := IntQuery(c, QueryParamFacilityID); err == nil {
+		if options.Facility, err = db.GetFacility(character.CorporationID, int32(facilityID)); err != nil {
+			return nil, fmt.Errorf("could not retrieve facility: %w", err)
+		}
+	}
+
+	if reactionFacilityID, err := IntQuery(c, QueryParamReactionFacilityID); err == nil {
+		if options.ReactionFacility, err = db.GetFacility(character.CorporationID, int32(reactionFacilityID)); err != nil {
+			return nil, fmt.Errorf("could not retrieve reaction facility: %w", err)
+		}
+	}
+
+	if decryptorTypeID, err := IntQuery(c, QueryParamDecryptorTypeID); err == nil {
+		options.DecryptorTypeID = int32(decryptorTypeID)
+	}
+
+	if relicTypeID, err := IntQuery(c, QueryParamRelicTypeID); err == nil {
+		options.RelicTypeID = int32(relicTypeID)
+	}
+
+	if runs, err := IntQuery(c, QueryParamRuns); err == nil {
+		options.Runs = int(runs)
+	}
+
+	if jobs, err := IntQuery(c, QueryParamJobs); err == nil {
+		options.Jobs = int(jobs)
+	}
+
+	// materials on hand are only used, if the location of the inventory is specified
+	if locationID, err := IntQuery(c, QueryParamInventoryLocationID); err == nil {
+		options.Inventory = &manufacturing.InventoryOptions{
+			CorporationID: character.CorporationID,
+			LocationID:    locationID,
+			Valuation:     model.InventoryValuationMarket,
+		}
+
+		if division, err := IntQuery(c, QueryParamInventoryDivision); err == nil {
+			options.Inventory.Division = int32(division)
+		}
+
+		if valuation := model.InventoryValuation(c.Query(QueryParamInventoryValuation)); valuation != "" {
+			if !valuation.IsValid() {
+				return nil, fmt.Errorf("unknown inventory valuation %q", valuation)
+			}
+
+			options.Inventory.Valuation = valuation
+		}
+	}
+
+	return options, nil
+}
+
+// TradeHubQuery parses the trade hub out of the query parameters. The hub can either be one of the known trade
+// hubs or a custom one, such as our home station, specified by its region and station.
+func TradeHubQuery(c *gin.Context, defaultHub model.TradeHub) (hub model.TradeHub, err error) {
+	hub = defaultHub
+
+	if name := c.Query(QueryParamHub); name != "" {
+		var ok bool
+		if hub, ok = model.TradeHubs[name]; !ok {
+			return hub, fmt.Errorf("unknown trade hub %q", name)
+		}
+	}
+
+	if regionID, err := IntQuery(c, QueryParamHubRegionID); err == nil {
+		hub = model.TradeHub{RegionID: int32(regionID)}
+
+		if stationID, err := IntQuery(c, QueryParamHubStationID); err == nil {
+			hub.StationID = stationID
+		}
+	}
+
+	return hub, nil
+}
+
 func GetManufacturing(c *gin.Context) {
 	var (
-		typeID int64
-		err    error
+		typeID  int64
+		options *manufacturing.Options
+		err     error
 	)
 
-	ME, err := IntQuery(c, QueryParamME)
-	TE, err := IntQuery(c, QueryParamTE)
-	facilityTax, err := strconv.ParseFloat(c.Query(QueryParamFacilityTax), 64)
-
 	character := c.Value(CharacterContext).(*model.Character)
 
+	if options, err = ManufacturingOptions(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
 	if typeID, err = IntParam(c, "id"); err != nil {
 		JSON(c, http.StatusBadRequest, nil, err)
 		return
@@ -78,22 +278,210 @@ func GetManufacturing(c *gin.Context) {
 	m := model.Manufacturing{}
 
 	// calculate it fresh
-	if err = manufacturing.NewManufacturing(character, int32(typeID), ME, TE, facilityTax, &m); err == nil {
+	if err = manufacturing.NewManufacturing(character, int32(typeID), options, &m); err == nil {
 		resp.Manufacturing = &m
 	}
 
 	JSON(c, http.StatusOK, m, err)
 
 	m = model.Manufacturing{}
+	options = manufacturing.NewOptions()
+	options.CorporationID = character.CorporationID
+
 	// calculate the manufacturing for the builder
-	if err = manufacturing.NewManufacturing(nil, int32(typeID), 10, 20, 0.1, &m); err == nil {
+	if err = manufacturing.NewManufacturing(nil, int32(typeID), options, &m); err == nil {
 		//cache.WriteCachedObject(m)
-		db.UpdateProfit(m)
+		db.UpdateProfit(character.CorporationID, m)
 	}
 }
 
+// GetShoppingList returns the materials of a manufacturing job that are not on hand and need to be bought. The
+// inventory is specified using the same query parameters as for GetManufacturing.
+func GetShoppingList(c *gin.Context) {
+	var (
+		typeID  int64
+		options *manufacturing.Options
+		err     error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if options, err = ManufacturingOptions(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if options.Inventory == nil {
+		JSON(c, http.StatusBadRequest, nil, fmt.Errorf("missing query parameter %s", QueryParamInventoryLocationID))
+		return
+	}
+
+	if typeID, err = IntParam(c, "id"); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	m := model.Manufacturing{}
+
+	if err = manufacturing.NewManufacturing(character, int32(typeID), options, &m); err != nil {
+		JSON(c, http.StatusOK, nil, err)
+		return
+	}
+
+	JSON(c, http.StatusOK, m.ShoppingList, nil)
+}
+
+// DefaultRunCounts are the run counts that are compared by GetRunCosts, if the caller does not specify any
+var DefaultRunCounts = []int32{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+// GetRunCosts returns how the costs and profit per item change with the number of runs of a job. Because of the
+// rounding of materials, small runs can be much more expensive per item.
+func GetRunCosts(c *gin.Context) {
+	var (
+		typeID  int64
+		options *manufacturing.Options
+		err     error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if options, err = ManufacturingOptions(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if typeID, err = IntParam(c, "id"); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	runCounts := TypeIDsQuery(c, QueryParamRunCounts)
+	if len(runCounts) == 0 {
+		runCounts = DefaultRunCounts
+	}
+
+	costs := []model.RunCost{}
+
+	for _, runs := range runCounts {
+		m := model.Manufacturing{}
+
+		o := *options
+		o.Runs = int(runs)
+
+		if err = manufacturing.NewManufacturing(character, int32(typeID), &o, &m); err != nil {
+			JSON(c, http.StatusOK, nil, err)
+			return
+		}
+
+		costs = append(costs, model.RunCost{
+			Runs:                m.Runs,
+			Jobs:                m.Jobs,
+			Units:               m.UnitsPerRun * m.Runs * m.Jobs,
+			MaterialCostPerItem: m.Costs.TotalMaterials / float64(m.UnitsPerRun*m.Runs*m.Jobs),
+			CostPerItem:         m.Costs.PerItem,
+			ProfitPerItem:       m.Profit.PerItem,
+		})
+
+		// the runs of blueprint copies cannot be changed, so all other run counts would yield the same
+		if m.Runs != int(runs) {
+			break
+		}
+	}
+
+	JSON(c, http.StatusOK, costs, nil)
+}
+
+func GetBuildTree(c *gin.Context) {
+	var (
+		typeID  int64
+		runs    int64
+		options *manufacturing.Options
+		err     error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if options, err = ManufacturingOptions(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if typeID, err = IntParam(c, "id"); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	// runs are optional and default to one
+	runs, _ = IntQuery(c, QueryParamRuns)
+
+	options.BuildDecisions = map[int32]bool{}
+	for _, typeID := range TypeIDsQuery(c, QueryParamBuild) {
+		options.BuildDecisions[typeID] = true
+	}
+	for _, typeID := range TypeIDsQuery(c, QueryParamBuy) {
+		options.BuildDecisions[typeID] = false
+	}
+
+	log.Debugf("Calculating build tree for typeID %d...", typeID)
+
+	tree, err := manufacturing.NewBuildTree(character, int32(typeID), int(runs), options)
+
+	JSON(c, http.StatusOK, tree, err)
+}
+
+func GetResearch(c *gin.Context) {
+	var (
+		typeID  int64
+		options *manufacturing.Options
+		err     error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if options, err = ManufacturingOptions(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if typeID, err = IntParam(c, "id"); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	// unless specified otherwise, we start with an unresearched blueprint
+	if c.Query(QueryParamME) == "" {
+		options.ME = 0
+	}
+
+	if c.Query(QueryParamTE) == "" {
+		options.TE = 0
+	}
+
+	if targetME, err := IntQuery(c, QueryParamTargetME); err == nil {
+		options.TargetME = targetME
+	}
+
+	if targetTE, err := IntQuery(c, QueryParamTargetTE); err == nil {
+		options.TargetTE = targetTE
+	}
+
+	if copies, err := IntQuery(c, QueryParamCopies); err == nil {
+		options.Copies = int(copies)
+	}
+
+	if copyRuns, err := IntQuery(c, QueryParamCopyRuns); err == nil {
+		options.CopyRuns = int(copyRuns)
+	}
+
+	log.Debugf("Calculating research for typeID %d...", typeID)
+
+	research, err := manufacturing.NewResearch(character, int32(typeID), options)
+
+	JSON(c, http.StatusOK, research, err)
+}
+
 func GetManufacturingProducts(c *gin.Context) {
-	//character := r.Context().Value(CharacterContext).(*model.Character)
+	character := c.Value(CharacterContext).(*model.Character)
 
 	array := strings.Split(c.Query(QueryParamCategoryIDs), SeparatorCategoryIDs)
 
@@ -106,10 +494,12 @@ func GetManufacturingProducts(c *gin.Context) {
 
 	options := db.NewSearchOptions()
 
+	options.CorporationID = character.CorporationID
 	options.NameFilter = c.Query(QueryParamNameFilter)
 	options.CategoryIDs = categoryIDs
 	options.MaxProductionCosts, _ = FloatQuery(c, QueryParamMaxProductionCosts)
 	options.HasRequiredSkillsOnly, _ = strconv.ParseBool(c.Query(QueryParamHasRequiredSkillsOnly))
+	options.OwnedBlueprintsOnly, _ = strconv.ParseBool(c.Query(QueryParamOwnedBlueprintsOnly))
 
 	if sortBy := c.Query(QueryParamSortBy); sortBy != "" {
 		array = strings.Split(sortBy, SeparatorSortBy)
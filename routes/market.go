@@ -19,14 +19,25 @@ package routes
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/antihax/goesi"
 	"github.com/gin-gonic/gin"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
 	"github.com/oxisto/titan/model"
 
 	"github.com/oxisto/titan/cache"
 )
 
+const (
+	QueryParamFrom = "from"
+	QueryParamTo   = "to"
+
+	// DefaultPriceHistoryDays is the time range of the price history, if none is specified
+	DefaultPriceHistoryDays = 30
+)
+
 func OpenMarketDetail(c *gin.Context) {
 	character := c.Value(CharacterContext).(*model.Character)
 
@@ -50,3 +61,39 @@ func OpenMarket(characterID int32, typeID int32, c *gin.Context) {
 		return
 	}
 }
+
+// GetPriceHistory returns the daily price history of a type at a trade hub. The time range can be specified
+// with the from and to query parameters as date (2006-01-02) and defaults to the last 30 days.
+func GetPriceHistory(c *gin.Context) {
+	var (
+		typeID int64
+		hub    model.TradeHub
+		from   time.Time
+		to     time.Time
+		err    error
+	)
+
+	if typeID, err = IntParam(c, "id"); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if hub, err = TradeHubQuery(c, manufacturing.DefaultTradeHub); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if from, to, err = DateRangeQuery(c, DefaultPriceHistoryDays); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	history := model.PriceHistory{
+		TypeID:     int32(typeID),
+		LocationID: hub.LocationID(),
+	}
+
+	history.History, err = db.GetPriceHistory(history.LocationID, history.TypeID, from, to)
+
+	JSON(c, http.StatusOK, history, err)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
+	"github.com/oxisto/titan/model"
+)
+
+// DefaultPlanCandidates is the number of most profitable products that are considered, if the plan request does
+// not specify any products
+const DefaultPlanCandidates = 25
+
+// PostProductionPlan creates a production plan for the characters in the request body. All characters need to
+// be in the caller's corporation. The manufacturing options are taken from the query parameters.
+func PostProductionPlan(c *gin.Context) {
+	var (
+		request model.ProductionPlanRequest
+		options *manufacturing.Options
+		err     error
+	)
+
+	character := c.Value(CharacterContext).(*model.Character)
+
+	if err = c.BindJSON(&request); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if request.Days <= 0 {
+		JSON(c, http.StatusBadRequest, nil, errors.New("the time window of the plan must be positive"))
+		return
+	}
+
+	if options, err = ManufacturingOptions(c, character); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	builders := []manufacturing.PlanBuilder{}
+	for _, planCharacter := range request.Characters {
+		builder := &model.Character{}
+
+		if err = cache.GetCharacter(planCharacter.CharacterID, builder); err != nil {
+			JSON(c, http.StatusBadRequest, nil, fmt.Errorf("could not retrieve character %d: %w", planCharacter.CharacterID, err))
+			return
+		}
+
+		if builder.CorporationID != character.CorporationID {
+			JSON(c, http.StatusBadRequest, nil, fmt.Errorf("character %d is not in your corporation", planCharacter.CharacterID))
+			return
+		}
+
+		builders = append(builders, manufacturing.PlanBuilder{
+			CharacterID:   planCharacter.CharacterID,
+			Builder:       builder,
+			Slots:         planCharacter.Slots,
+			ReactionSlots: planCharacter.ReactionSlots,
+		})
+	}
+
+	if len(request.TypeIDs) == 0 {
+		search := db.NewSearchOptions()
+		search.Limit = DefaultPlanCandidates
+		search.CorporationID = character.CorporationID
+		search.OwnedBlueprintsOnly = options.CorporationID != 0
+
+		var types []db.ProductTypeResult
+		if types, err = db.GetProductTypes(search); err != nil {
+			JSON(c, http.StatusOK, nil, err)
+			return
+		}
+
+		for _, t := range types {
+			request.TypeIDs = append(request.TypeIDs, int32(t.TypeID))
+		}
+	}
+
+	plan, err := manufacturing.NewProductionPlan(&request, builders, options)
+
+	JSON(c, http.StatusOK, plan, err)
+}
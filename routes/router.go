@@ -20,11 +20,14 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/oxisto/titan/cache"
+	"github.com/oxisto/titan/datafetch"
 	"github.com/oxisto/titan/model"
 	"github.com/oxisto/titan/routes/auth"
 
@@ -36,16 +39,24 @@ const (
 )
 
 var (
-	limitToCorporationId int32
-	log                  *logrus.Entry
+	allowedCorporations map[int32]bool
+	scheduler           *datafetch.Scheduler
+	log                 *logrus.Entry
 )
 
 func init() {
 	log = logrus.WithField("component", "routes")
 }
 
-func NewRouter(corporationId int32) *gin.Engine {
-	limitToCorporationId = corporationId
+// NewRouter creates the router for all API routes. If corporation IDs are specified, only members of these
+// corporations are allowed to access the API.
+func NewRouter(corporationIDs []int32, dataScheduler *datafetch.Scheduler) *gin.Engine {
+	allowedCorporations = map[int32]bool{}
+	for _, corporationID := range corporationIDs {
+		allowedCorporations[corporationID] = true
+	}
+
+	scheduler = dataScheduler
 
 	options := auth.DefaultOptions
 	options.JWTKeySupplier = func(token *jwt.Token) (interface{}, error) {
@@ -71,29 +82,56 @@ func NewRouter(corporationId int32) *gin.Engine {
 		character := api.Group("/character")
 		{
 			character.GET("", GetCharacter)
+			character.GET("settings", GetSettings)
+			character.PUT("settings", PutSettings)
 		}
 
 		corporation := api.Group("/corporation")
 		{
 			corporation.GET("", GetCorporation)
 			corporation.GET("wallets", GetCorporationWallets)
+			corporation.GET("wallets/history", GetBalanceHistory)
+			corporation.POST("wallets/forecast", PostCashFlowForecast)
+			corporation.GET("assets", GetCorporationAssets)
+			corporation.GET("journal", GetCorporationJournal)
+			corporation.GET("holdings", GetCorporationHoldings)
+			corporation.GET("reports/pnl", GetProfitAndLossReport)
 		}
 
 		manufacturing := api.Group("/manufacturing")
 		{
 			manufacturing.GET("", GetManufacturingProducts)
+			manufacturing.POST("plan", PostProductionPlan)
 			manufacturing.GET(":id", GetManufacturing)
+			manufacturing.GET(":id/tree", GetBuildTree)
+			manufacturing.GET(":id/research", GetResearch)
+			manufacturing.GET(":id/shopping-list", GetShoppingList)
+			manufacturing.GET(":id/runs", GetRunCosts)
 		}
 		api.GET("/manufacturing-categories", GetManufacturingCategories)
 
+		facilities := api.Group("/facilities")
+		{
+			facilities.GET("", GetFacilities)
+			facilities.POST("", PostFacility)
+			facilities.GET(":id", GetFacility)
+			facilities.PUT(":id", PutFacility)
+			facilities.DELETE(":id", DeleteFacility)
+		}
+
 		industry := api.Group("/industry")
 		{
 			industry.GET("/jobs", GetIndustryJobs)
+			industry.GET("/blueprints", GetCorporationBlueprints)
+			industry.GET("/profit", GetRealizedProfit)
 		}
 
+		api.GET("/datafetch/status", GetDataFetchStatus)
+
 		market := api.Group("/market")
 		{
 			market.POST("/:view", OpenMarketDetail)
+			market.GET("/:id/history", GetPriceHistory)
 		}
 	}
 
@@ -110,7 +148,7 @@ func CharacterRequired(c *gin.Context) {
 	character := &model.Character{}
 	cache.GetCharacter(int32(claims.CharacterID), character)
 
-	if limitToCorporationId != 0 && character.CorporationID != limitToCorporationId {
+	if len(allowedCorporations) > 0 && !allowedCorporations[character.CorporationID] {
 		c.String(http.StatusForbidden, "The corporation you are in is not allowed to access this service")
 		c.Abort()
 		return
@@ -136,6 +174,42 @@ func FloatQuery(c *gin.Context, key string) (i float64, err error) {
 	return strconv.ParseFloat(c.Query(key), 64)
 }
 
+// TypeIDsQuery parses a comma-separated list of type IDs. Invalid entries are ignored.
+func TypeIDsQuery(c *gin.Context, key string) []int32 {
+	typeIDs := []int32{}
+
+	for _, v := range strings.Split(c.Query(key), SeparatorTypeIDs) {
+		if i, err := strconv.ParseInt(v, 10, 32); err == nil {
+			typeIDs = append(typeIDs, int32(i))
+		}
+	}
+
+	return typeIDs
+}
+
+// DateRangeQuery parses the from and to query parameters as date (2006-01-02). The to date is included as a
+// whole day and defaults to now, the from date defaults to the specified number of days before it.
+func DateRangeQuery(c *gin.Context, defaultDays int) (from time.Time, to time.Time, err error) {
+	to = time.Now()
+	if value := c.Query(QueryParamTo); value != "" {
+		if to, err = time.Parse("2006-01-02", value); err != nil {
+			return from, to, err
+		}
+
+		// include the whole day
+		to = to.AddDate(0, 0, 1)
+	}
+
+	from = to.AddDate(0, 0, -defaultDays)
+	if value := c.Query(QueryParamFrom); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return from, to, err
+		}
+	}
+
+	return from, to, nil
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
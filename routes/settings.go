@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Christian Banse
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routes
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oxisto/titan/db"
+	"github.com/oxisto/titan/manufacturing"
+	"github.com/oxisto/titan/model"
+)
+
+func GetSettings(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+
+	settings, err := db.GetSettings(character.CharacterID)
+
+	// characters without settings use the defaults
+	if err == sql.ErrNoRows {
+		options := manufacturing.NewOptions()
+
+		settings = &model.Settings{
+			CharacterID:      character.CharacterID,
+			TradeHub:         options.Hub,
+			MaterialStrategy: options.MaterialStrategy,
+			ProductStrategy:  options.ProductStrategy,
+		}
+		err = nil
+	}
+
+	JSON(c, http.StatusOK, settings, err)
+}
+
+func PutSettings(c *gin.Context) {
+	character := c.Value(CharacterContext).(*model.Character)
+	settings := &model.Settings{}
+
+	if err := c.BindJSON(settings); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	if err := validateSettings(settings); err != nil {
+		JSON(c, http.StatusBadRequest, nil, err)
+		return
+	}
+
+	settings.CharacterID = character.CharacterID
+
+	err := db.UpdateSettings(settings)
+
+	JSON(c, http.StatusOK, settings, err)
+}
+
+func validateSettings(settings *model.Settings) error {
+	if settings.TradeHub.RegionID == 0 {
+		return errors.New("trade hub needs a region")
+	}
+
+	if !settings.MaterialStrategy.IsValid() {
+		return fmt.Errorf("unknown price strategy %q", settings.MaterialStrategy)
+	}
+
+	if !settings.ProductStrategy.IsValid() {
+		return fmt.Errorf("unknown price strategy %q", settings.ProductStrategy)
+	}
+
+	return nil
+}
@@ -1,8 +1,10 @@
 CREATE TABLE profit (
+    "corporationID" bigint NOT NULL,
     "typeID" integer NOT NULL,
     "basedOnSellPrice" double precision,
     "basedOnBuyPrice" double precision,
-    CONSTRAINT profit_pkey PRIMARY KEY ("typeID")
+    "hasBlueprint" boolean NOT NULL DEFAULT TRUE,
+    CONSTRAINT profit_pkey PRIMARY KEY ("corporationID", "typeID")
 );
 
 CREATE TABLE journal (
@@ -16,24 +18,45 @@ CREATE TABLE journal (
     "secondPartyID" integer,
     "corporationID" bigint NOT NULL,
     "division" integer NOT NULL,
+    "contextID" bigint,
+    "contextIDType" text COLLATE pg_catalog. "default",
+    reason text COLLATE pg_catalog. "default",
+    tax double precision,
+    "taxReceiverID" integer,
     CONSTRAINT journal_pkey PRIMARY KEY (
+        "corporationID",
+        division,
         id
     )
 );
 
+CREATE TABLE "walletBalances" (
+    "corporationID" bigint NOT NULL,
+    division integer NOT NULL,
+    date timestamp WITH time zone NOT NULL,
+    balance double precision NOT NULL,
+    CONSTRAINT "walletBalances_pkey" PRIMARY KEY (
+        "corporationID",
+        division,
+        date
+    )
+);
+
 CREATE TABLE public.transactions (
     "transactionID" bigint NOT NULL,
     "clientID" integer,
     date timestamp WITH time zone,
     "isBuy" boolean,
     "journalRefID" bigint,
-    "locationID" integer,
+    "locationID" bigint,
     quantity integer,
     "typeID" integer,
     "unitPrice" double precision,
     "corporationID" bigint NOT NULL,
     "division" integer NOT NULL,
     CONSTRAINT transactions_pkey PRIMARY KEY (
+        "corporationID",
+        division,
         "transactionID"
     )
 );
@@ -60,7 +83,149 @@ CREATE TABLE public."industryJobs" (
 	"runs" integer NOT NULL,
 	"succesfulRuns" integer NOT NULL,
 	"status" text COLLATE pg_catalog. "default" NOT NULL,
+	"corporationID" integer NOT NULL,
     CONSTRAINT industryJobs_pkey PRIMARY KEY (
         "jobID"
     )
-)
\ No newline at end of file
+);
+
+CREATE TABLE public.facilities (
+    "facilityID" serial NOT NULL,
+    "corporationID" integer NOT NULL,
+    name text COLLATE pg_catalog. "default" NOT NULL,
+    "structureType" text COLLATE pg_catalog. "default" NOT NULL,
+    "solarSystemID" integer NOT NULL,
+    security text COLLATE pg_catalog. "default" NOT NULL,
+    tax double precision NOT NULL,
+    rigs jsonb NOT NULL DEFAULT '[]',
+    CONSTRAINT facilities_pkey PRIMARY KEY (
+        "facilityID"
+    )
+);
+
+CREATE TABLE public."corporationBlueprints" (
+    "itemID" bigint NOT NULL,
+    "corporationID" integer NOT NULL,
+    "typeID" integer NOT NULL,
+    "locationID" bigint NOT NULL,
+    "locationFlag" text COLLATE pg_catalog. "default" NOT NULL,
+    "quantity" integer NOT NULL,
+    "materialEfficiency" integer NOT NULL,
+    "timeEfficiency" integer NOT NULL,
+    "runs" integer NOT NULL,
+    CONSTRAINT corporationBlueprints_pkey PRIMARY KEY (
+        "itemID"
+    )
+);
+
+CREATE TABLE public.settings (
+    "characterID" integer NOT NULL,
+    "hubName" text COLLATE pg_catalog. "default" NOT NULL,
+    "hubRegionID" integer NOT NULL,
+    "hubStationID" bigint NOT NULL,
+    "materialStrategy" text COLLATE pg_catalog. "default" NOT NULL,
+    "productStrategy" text COLLATE pg_catalog. "default" NOT NULL,
+    CONSTRAINT settings_pkey PRIMARY KEY (
+        "characterID"
+    )
+);
+
+CREATE TABLE public.prices (
+    "typeID" integer NOT NULL,
+    "locationID" bigint NOT NULL,
+    date timestamp WITH time zone NOT NULL,
+    "buyPercentile" double precision NOT NULL,
+    "buyVolume" bigint NOT NULL,
+    "buyOrderCount" integer NOT NULL,
+    "sellPercentile" double precision NOT NULL,
+    "sellVolume" bigint NOT NULL,
+    "sellOrderCount" integer NOT NULL,
+    CONSTRAINT prices_pkey PRIMARY KEY (
+        "typeID",
+        "locationID",
+        date
+    )
+);
+
+CREATE TABLE public."marketHistory" (
+    "regionID" integer NOT NULL,
+    "typeID" integer NOT NULL,
+    date date NOT NULL,
+    average double precision NOT NULL,
+    highest double precision NOT NULL,
+    lowest double precision NOT NULL,
+    "orderCount" bigint NOT NULL,
+    volume bigint NOT NULL,
+    CONSTRAINT marketHistory_pkey PRIMARY KEY (
+        "regionID",
+        "typeID",
+        date
+    )
+);
+
+CREATE TABLE public.assets (
+    "itemID" bigint NOT NULL,
+    "corporationID" integer NOT NULL,
+    "typeID" integer NOT NULL,
+    quantity integer NOT NULL,
+    "isSingleton" boolean NOT NULL,
+    "locationID" bigint NOT NULL,
+    "locationFlag" text COLLATE pg_catalog. "default" NOT NULL,
+    "locationType" text COLLATE pg_catalog. "default" NOT NULL,
+    "rootLocationID" bigint NOT NULL,
+    division integer NOT NULL,
+    CONSTRAINT assets_pkey PRIMARY KEY (
+        "itemID"
+    )
+);
+
+CREATE TABLE public.structures (
+    "structureID" bigint NOT NULL,
+    name text COLLATE pg_catalog. "default" NOT NULL,
+    "solarSystemID" integer NOT NULL,
+    "typeID" integer NOT NULL,
+    CONSTRAINT structures_pkey PRIMARY KEY (
+        "structureID"
+    )
+);
+
+-- Migrations of databases that were created by an older version. CREATE TABLE fails for existing tables, so
+-- changed tables are altered here. All statements can be run repeatedly.
+
+-- journal: context of an entry and entries are identified per division
+ALTER TABLE journal
+    ADD COLUMN IF NOT EXISTS "contextID" bigint,
+    ADD COLUMN IF NOT EXISTS "contextIDType" text COLLATE pg_catalog. "default",
+    ADD COLUMN IF NOT EXISTS reason text COLLATE pg_catalog. "default",
+    ADD COLUMN IF NOT EXISTS tax double precision,
+    ADD COLUMN IF NOT EXISTS "taxReceiverID" integer;
+
+ALTER TABLE journal
+    DROP CONSTRAINT IF EXISTS journal_pkey,
+    ADD CONSTRAINT journal_pkey PRIMARY KEY ("corporationID", division, id);
+
+-- profit: profits are cached per corporation. Older rows were calculated without a corporation.
+ALTER TABLE profit
+    ADD COLUMN IF NOT EXISTS "corporationID" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE profit
+    ALTER COLUMN "corporationID" DROP DEFAULT,
+    DROP CONSTRAINT IF EXISTS profit_pkey,
+    ADD CONSTRAINT profit_pkey PRIMARY KEY ("corporationID", "typeID");
+
+-- transactions: structure IDs do not fit into an integer and transactions are identified per division
+ALTER TABLE transactions
+    ALTER COLUMN "locationID" TYPE bigint,
+    DROP CONSTRAINT IF EXISTS transactions_pkey,
+    ADD CONSTRAINT transactions_pkey PRIMARY KEY ("corporationID", division, "transactionID");
+
+-- industryJobs: jobs belong to a corporation. Older jobs get their corporation, once they are fetched again.
+ALTER TABLE "industryJobs"
+    ADD COLUMN IF NOT EXISTS "corporationID" integer NOT NULL DEFAULT 0;
+
+ALTER TABLE "industryJobs"
+    ALTER COLUMN "corporationID" DROP DEFAULT;
+
+-- profit: whether the corporation owns or can invent the blueprint of a product
+ALTER TABLE profit
+    ADD COLUMN IF NOT EXISTS "hasBlueprint" boolean NOT NULL DEFAULT TRUE;